@@ -0,0 +1,199 @@
+// Package audit emits structured audit events (who did what on which entity, and what changed)
+// to pluggable sinks: a database table, a Kafka topic or a file.
+//
+//	auditor := audit.New(audit.Config{Sinks: []audit.Sink{audit.NewSQLSink(db, "audit_events")}})
+//	handler = auditor.Middleware(handler)  // captures the ip, user agent and trace id of the requests
+//
+//	err := auditor.Record(ctx, "update", "merchant", merchant.ID, before, after)
+//
+// The actor is taken from the `auth.Principal` in the context, unless it's set explicitly with
+// `WithActor` (ie: for batch jobs).
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/astropay/go-tools/auth"
+	"github.com/astropay/go-tools/common"
+)
+
+// Audit errors
+var (
+	ErrMissingAction = errors.New("audit event must have an action")
+	ErrNoSinks       = errors.New("auditor has no sinks")
+)
+
+// Actor is who performed the action
+type Actor struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"` // ie: user, service, job
+}
+
+// Event is the canonical audit event
+type Event struct {
+	ID        string                   `json:"id"`
+	Time      time.Time                `json:"time"`
+	Actor     Actor                    `json:"actor"`
+	Action    string                   `json:"action"`
+	Entity    string                   `json:"entity"`
+	EntityID  string                   `json:"entity_id"`
+	Changes   map[string]common.Change `json:"changes,omitempty"`
+	IP        string                   `json:"ip,omitempty"`
+	UserAgent string                   `json:"user_agent,omitempty"`
+	TraceID   string                   `json:"trace_id,omitempty"`
+	Metadata  map[string]string        `json:"metadata,omitempty"`
+}
+
+// Sink writes the audit events to a destination
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+// SinkFunc is an adapter to allow the use of ordinary functions as sinks
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Write calls f(ctx, event)
+func (f SinkFunc) Write(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Config holds the auditor configuration
+type Config struct {
+	Sinks []Sink
+
+	// DiffTag is the struct tag used to name the changed fields (default "json")
+	DiffTag string
+
+	// Redact lists the changed fields whose values are replaced by "[REDACTED]"
+	Redact []string
+
+	// TrustedProxies are the networks of the proxies in front of the service; the middleware takes
+	// the caller ip from X-Forwarded-For only on requests coming from them (see geo.ClientIP)
+	TrustedProxies []*net.IPNet
+}
+
+// Auditor completes the events with the context data and writes them to all the sinks
+type Auditor struct {
+	config Config
+	redact map[string]bool
+}
+
+// New creates an auditor
+func New(config Config) *Auditor {
+	if config.DiffTag == "" {
+		config.DiffTag = "json"
+	}
+
+	a := &Auditor{config: config, redact: make(map[string]bool)}
+	for _, field := range config.Redact {
+		a.redact[field] = true
+	}
+	return a
+}
+
+// Record emits an event for the action, with the changes between `before` and `after` (structs or
+// pointers to structs of the same type; any of them can be nil on creation or deletion)
+func (a *Auditor) Record(ctx context.Context, action, entity string, entityID interface{}, before, after interface{}) error {
+	event := Event{Action: action, Entity: entity, EntityID: fmt.Sprint(entityID)}
+
+	if before != nil || after != nil {
+		changes, err := common.Diff(before, after, a.config.DiffTag)
+		if err != nil {
+			return err
+		}
+		event.Changes = changes
+	}
+
+	return a.Emit(ctx, event)
+}
+
+// Emit completes the event (id, time, actor, request data) and writes it to all the sinks; the
+// errors of the sinks are joined, but a failing sink doesn't stop the others
+func (a *Auditor) Emit(ctx context.Context, event Event) error {
+	if event.Action == "" {
+		return ErrMissingAction
+	}
+
+	if len(a.config.Sinks) == 0 {
+		return ErrNoSinks
+	}
+
+	a.complete(ctx, &event)
+
+	var failures []string
+	for _, sink := range a.config.Sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("audit sinks failed: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (a *Auditor) complete(ctx context.Context, event *Event) {
+	if event.ID == "" {
+		event.ID = newID()
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	if event.Actor.ID == "" {
+		if actor, found := ctx.Value(actorKey{}).(Actor); found {
+			event.Actor = actor
+		} else if principal, found := auth.FromContext(ctx); found && principal != nil {
+			event.Actor = Actor{ID: principal.ID, Name: principal.Name, Type: "principal"}
+		}
+	}
+
+	if info, found := ctx.Value(requestKey{}).(requestInfo); found {
+		if event.IP == "" {
+			event.IP = info.ip
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = info.userAgent
+		}
+		if event.TraceID == "" {
+			event.TraceID = info.traceID
+		}
+	}
+
+	// the changes are copied, so the caller's map isn't redacted
+	if len(event.Changes) > 0 {
+		changes := make(map[string]common.Change, len(event.Changes))
+		for field, change := range event.Changes {
+			if a.redact[field] {
+				change = common.Change{From: redacted(change.From), To: redacted(change.To)}
+			}
+			changes[field] = change
+		}
+		event.Changes = changes
+	}
+}
+
+func redacted(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return "[REDACTED]"
+}
+
+// returns a random 32 hex chars id
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/astropay/go-tools/auth"
+	"github.com/astropay/go-tools/common"
+	"github.com/astropay/go-tools/fsm"
+	"github.com/astropay/go-tools/geo"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type merchant struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	APIToken string `json:"api_token"`
+}
+
+// sink that keeps the events in memory
+type memorySink struct {
+	events []Event
+}
+
+func (s *memorySink) Write(ctx context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	sink := new(memorySink)
+	auditor := New(Config{Sinks: []Sink{sink}, Redact: []string{"api_token"}})
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{ID: "backoffice-7", Name: "John"})
+	before := &merchant{ID: 10, Name: "Shop", Status: "active", APIToken: "a"}
+	after := &merchant{ID: 10, Name: "Shop", Status: "blocked", APIToken: "b"}
+
+	assert.Nil(t, auditor.Record(ctx, "update", "merchant", before.ID, before, after))
+
+	if assert.Len(t, sink.events, 1) {
+		event := sink.events[0]
+		assert.Len(t, event.ID, 32)
+		assert.False(t, event.Time.IsZero())
+		assert.Equal(t, Actor{ID: "backoffice-7", Name: "John", Type: "principal"}, event.Actor)
+		assert.Equal(t, "10", event.EntityID)
+		assert.Equal(t, map[string]common.Change{
+			"status":    {From: "active", To: "blocked"},
+			"api_token": {From: "[REDACTED]", To: "[REDACTED]"},
+		}, event.Changes)
+	}
+
+	// explicit actors take precedence over the principal
+	ctx = WithActor(ctx, Actor{ID: "settlement-job", Type: "job"})
+	assert.Nil(t, auditor.Record(ctx, "delete", "merchant", 10, before, nil))
+	assert.Equal(t, "settlement-job", sink.events[1].Actor.ID)
+	assert.Len(t, sink.events[1].Changes, 4)
+
+	// the caller's changes aren't redacted
+	changes := map[string]common.Change{"api_token": {From: "a", To: "b"}}
+	assert.Nil(t, auditor.Emit(ctx, Event{Action: "rotate", Entity: "merchant", EntityID: "10", Changes: changes}))
+	assert.Equal(t, common.Change{From: "a", To: "b"}, changes["api_token"])
+	assert.Equal(t, common.Change{From: "[REDACTED]", To: "[REDACTED]"}, sink.events[2].Changes["api_token"])
+
+	assert.Equal(t, ErrMissingAction, auditor.Emit(ctx, Event{}))
+	assert.Equal(t, ErrNoSinks, New(Config{}).Emit(ctx, Event{Action: "login"}))
+}
+
+func TestEmitFailingSink(t *testing.T) {
+	sink := new(memorySink)
+	failing := SinkFunc(func(ctx context.Context, event Event) error { return errors.New("broker unavailable") })
+
+	err := New(Config{Sinks: []Sink{failing, sink}}).Emit(context.Background(), Event{Action: "login"})
+	assert.Equal(t, "audit sinks failed: broker unavailable", err.Error())
+	assert.Len(t, sink.events, 1)
+}
+
+func TestMiddleware(t *testing.T) {
+	sink := new(memorySink)
+	proxies, _ := geo.ParseNetworks("10.0.0.0/8")
+	auditor := New(Config{Sinks: []Sink{sink}, TrustedProxies: proxies})
+
+	handler := auditor.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditor.Emit(r.Context(), Event{Action: "login", Entity: "user", EntityID: "1"})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "8.8.8.8, 200.1.2.3, 10.0.0.1")
+	req.Header.Set("User-Agent", "tests")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the forwarded address is ignored on requests that don't come from a proxy
+	req = httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "200.1.2.4:5000"
+	req.Header.Set("X-Forwarded-For", "8.8.8.8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.Len(t, sink.events, 2) {
+		assert.Equal(t, "200.1.2.3", sink.events[0].IP)
+		assert.Equal(t, "tests", sink.events[0].UserAgent)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sink.events[0].TraceID)
+		assert.Equal(t, "200.1.2.4", sink.events[1].IP)
+	}
+
+	// without trusted proxies, the header is never used
+	auditor = New(Config{Sinks: []Sink{sink}})
+	handler = auditor.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditor.Emit(r.Context(), Event{Action: "login", Entity: "user", EntityID: "1"})
+	}))
+
+	req = httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "8.8.8.8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.Len(t, sink.events, 3) {
+		assert.Equal(t, "10.0.0.2", sink.events[2].IP)
+	}
+}
+
+type payment struct {
+	id    string
+	state fsm.State
+}
+
+func (p *payment) State() fsm.State         { return p.state }
+func (p *payment) SetState(state fsm.State) { p.state = state }
+
+func TestFSMListener(t *testing.T) {
+	sink := new(memorySink)
+	auditor := New(Config{Sinks: []Sink{sink}})
+
+	machine, _ := fsm.New(fsm.Config{
+		Name:        "payment",
+		Transitions: []fsm.Transition{{Event: "approve", From: []fsm.State{"pending"}, To: "approved"}},
+		Listeners: []fsm.Listener{auditor.FSMListener(func(entity fsm.Stateful) string {
+			return entity.(*payment).id
+		}, nil)},
+	})
+
+	assert.Nil(t, machine.Fire(context.Background(), &payment{id: "P-1", state: "pending"}, "approve"))
+	if assert.Len(t, sink.events, 1) {
+		assert.Equal(t, "approve", sink.events[0].Action)
+		assert.Equal(t, "payment", sink.events[0].Entity)
+		assert.Equal(t, "P-1", sink.events[0].EntityID)
+		assert.Equal(t, common.Change{From: "pending", To: "approved"}, sink.events[0].Changes["state"])
+	}
+}
+
+func TestSQLSink(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE audit_events (id VARCHAR(32), event_time DATETIME, actor_id VARCHAR(100), actor_type VARCHAR(20),
+		action VARCHAR(50), entity VARCHAR(50), entity_id VARCHAR(100), changes TEXT, ip VARCHAR(45), user_agent VARCHAR(255),
+		trace_id VARCHAR(64), metadata TEXT)`)
+	if err != nil {
+		t.Fatalf("Error creating table: %s", err.Error())
+	}
+
+	auditor := New(Config{Sinks: []Sink{NewSQLSink(db, "audit_events")}})
+	assert.Nil(t, auditor.Record(context.Background(), "create", "merchant", 10, nil, &merchant{ID: 10, Name: "Shop"}))
+
+	var changes string
+	var metadata sql.NullString
+	db.QueryRow("SELECT changes, metadata FROM audit_events WHERE entity_id = '10'").Scan(&changes, &metadata)
+	assert.Contains(t, changes, `"name":{"from":null,"to":"Shop"}`)
+	assert.False(t, metadata.Valid)
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs", "audit.log")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink() returned an error: %s", err.Error())
+	}
+
+	auditor := New(Config{Sinks: []Sink{sink}})
+	auditor.Emit(context.Background(), Event{Action: "login"})
+	auditor.Emit(context.Background(), Event{Action: "logout"})
+	assert.Nil(t, sink.Close())
+
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	var actions []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		event := Event{}
+		json.Unmarshal(scanner.Bytes(), &event)
+		actions = append(actions, event.Action)
+	}
+	assert.Equal(t, []string{"login", "logout"}, actions)
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/astropay/go-tools/common"
+	"github.com/astropay/go-tools/fsm"
+	"github.com/astropay/go-tools/geo"
+)
+
+type actorKey struct{}
+type requestKey struct{}
+
+// request data captured by the middleware
+type requestInfo struct {
+	ip        string
+	userAgent string
+	traceID   string
+}
+
+// WithActor returns a copy of ctx carrying the actor of the events
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Middleware stores the caller ip, user agent and trace id of the requests in their context, so
+// the events emitted by the handlers include them. The ip is taken from X-Forwarded-For only on
+// requests from the trusted proxies, and the trace id from the X-Request-Id header, or from the
+// W3C traceparent header.
+func (a *Auditor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := requestInfo{ip: a.clientIP(r), userAgent: r.UserAgent(), traceID: r.Header.Get("X-Request-Id")}
+
+		if info.traceID == "" {
+			// traceparent: version-traceid-parentid-flags
+			if parts := strings.Split(r.Header.Get("traceparent"), "-"); len(parts) == 4 {
+				info.traceID = parts[1]
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestKey{}, info)))
+	})
+}
+
+// FSMListener returns a state machine listener that emits an event on each transition, with the
+// state change and the transition event as metadata
+func (a *Auditor) FSMListener(entityID func(entity fsm.Stateful) string, onError func(err error)) fsm.Listener {
+	return func(ctx context.Context, entity fsm.Stateful, t fsm.TransitionEvent) {
+		err := a.Emit(ctx, Event{
+			Action:   string(t.Event),
+			Entity:   t.Machine,
+			EntityID: entityID(entity),
+			Changes:  map[string]common.Change{"state": {From: t.From.String(), To: t.To.String()}},
+			Time:     t.At.UTC(),
+		})
+
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// returns the caller address, from X-Forwarded-For when the request comes from a trusted proxy
+func (a *Auditor) clientIP(r *http.Request) string {
+	if ip := geo.ClientIP(r, a.config.TrustedProxies); ip != nil {
+		return ip.String()
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/astropay/go-tools/files"
+)
+
+// SQLSink inserts the events in a table with the columns id, event_time, actor_id, actor_type,
+// action, entity, entity_id, changes (JSON text), ip, user_agent, trace_id and metadata (JSON text)
+type SQLSink struct {
+	db    *sql.DB
+	query string
+}
+
+// NewSQLSink creates a sink that writes to the indicated table
+func NewSQLSink(db *sql.DB, table string) *SQLSink {
+	query := fmt.Sprintf("INSERT INTO %s (id, event_time, actor_id, actor_type, action, entity, entity_id, changes, ip, user_agent, trace_id, metadata) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", table)
+	return &SQLSink{db: db, query: query}
+}
+
+// Write inserts the event
+func (s *SQLSink) Write(ctx context.Context, event Event) error {
+	changes, err := jsonOrNull(event.Changes, len(event.Changes) == 0)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := jsonOrNull(event.Metadata, len(event.Metadata) == 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.query, event.ID, event.Time, event.Actor.ID, event.Actor.Type, event.Action,
+		event.Entity, event.EntityID, changes, event.IP, event.UserAgent, event.TraceID, metadata)
+	return err
+}
+
+// marshals the value as JSON, or returns nil when it's empty
+func jsonOrNull(value interface{}, empty bool) (interface{}, error) {
+	if empty {
+		return nil, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Producer publishes a message to a Kafka topic; it's implemented by a thin adapter over the
+// Kafka client used by the service
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaSink publishes the events as JSON to a topic, keyed by entity so the events of an
+// entity keep their order
+type KafkaSink struct {
+	producer Producer
+	topic    string
+}
+
+// NewKafkaSink creates a sink that publishes to the indicated topic
+func NewKafkaSink(producer Producer, topic string) *KafkaSink {
+	return &KafkaSink{producer: producer, topic: topic}
+}
+
+// Write publishes the event
+func (s *KafkaSink) Write(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.producer.Produce(ctx, s.topic, []byte(event.Entity+":"+event.EntityID), value)
+}
+
+// FileSink appends the events to a file, one JSON document per line
+type FileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewFileSink opens (or creates) the file to append the events
+func NewFileSink(path string) (s *FileSink, err error) {
+	if err = files.EnsureDir(filepath.Dir(path)); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{file: file}, nil
+}
+
+// Write appends the event
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
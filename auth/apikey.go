@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// default API key header
+const defaultAPIKeyHeader = "X-Api-Key"
+
+// KeyStore looks up API keys by their hash (see `HashAPIKey`); keys must never be stored
+// in plain text. ErrKeyNotFound must be returned when the hash doesn't exist.
+type KeyStore interface {
+	LookupHash(ctx context.Context, hash string) (*Principal, error)
+}
+
+// KeyStoreFunc is an adapter to allow the use of ordinary functions as key stores
+type KeyStoreFunc func(ctx context.Context, hash string) (*Principal, error)
+
+// LookupHash calls f(ctx, hash)
+func (f KeyStoreFunc) LookupHash(ctx context.Context, hash string) (*Principal, error) {
+	return f(ctx, hash)
+}
+
+// HashAPIKey returns the hash used to store and look up an API key (hex encoded SHA-256)
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyConfig holds the API key authentication configuration
+type APIKeyConfig struct {
+	Store KeyStore
+
+	// Header that carries the key (default X-Api-Key)
+	Header string
+
+	// CacheTTL is how long a valid key is cached (default 1 minute); a negative value disables the cache
+	CacheTTL time.Duration
+
+	// NegativeCacheTTL is how long an unknown key is cached, so invalid keys don't hit the
+	// store on every request (default 10 seconds); a negative value disables it
+	NegativeCacheTTL time.Duration
+
+	// CacheSize is the maximum number of cached keys, valid and unknown (default 10000); the
+	// oldest ones are evicted when it's reached
+	CacheSize int
+
+	// ErrorHandler writes the response when authentication fails (default 401, or 500 without
+	// details when the store fails)
+	ErrorHandler ErrorHandler
+}
+
+// APIKeyAuthenticator validates API keys against a `KeyStore`, caching the results
+type APIKeyAuthenticator struct {
+	config APIKeyConfig
+
+	mutex sync.RWMutex
+	cache *expiringMap
+
+	// used for testing
+	now func() time.Time
+}
+
+// NewAPIKeyAuthenticator creates a new API key authenticator
+func NewAPIKeyAuthenticator(config APIKeyConfig) *APIKeyAuthenticator {
+	if config.Header == "" {
+		config.Header = defaultAPIKeyHeader
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = time.Minute
+	}
+	if config.NegativeCacheTTL == 0 {
+		config.NegativeCacheTTL = 10 * time.Second
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = 10000
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = defaultErrorHandler
+	}
+
+	return &APIKeyAuthenticator{config: config, cache: newExpiringMap(config.CacheSize), now: time.Now}
+}
+
+// Authenticate returns the principal that owns the key; store failures are wrapped, so they
+// can be told apart from the authentication errors
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (p *Principal, err error) {
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	hash := HashAPIKey(key)
+	now := a.now()
+
+	a.mutex.RLock()
+	entry, cached := a.cache.get(hash, now)
+	a.mutex.RUnlock()
+
+	if cached {
+		if entry == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return entry.(*Principal), nil
+	}
+
+	if p, err = a.config.Store.LookupHash(ctx, hash); err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			a.store(hash, nil, now.Add(a.config.NegativeCacheTTL), a.config.NegativeCacheTTL > 0)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("looking up the key: %w", err)
+	}
+
+	a.store(hash, p, now.Add(a.config.CacheTTL), a.config.CacheTTL > 0)
+	return
+}
+
+// Invalidate removes a key from the cache (ie: after revoking it)
+func (a *APIKeyAuthenticator) Invalidate(key string) {
+	a.mutex.Lock()
+	a.cache.delete(HashAPIKey(key))
+	a.mutex.Unlock()
+}
+
+// Middleware authenticates the request and adds the principal to its context
+func (a *APIKeyAuthenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r.Context(), r.Header.Get(a.config.Header))
+		if err != nil {
+			a.config.ErrorHandler(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+	})
+}
+
+// adds an entry to the cache, evicting the oldest one when it's full
+func (a *APIKeyAuthenticator) store(hash string, p *Principal, expires time.Time, enabled bool) {
+	if !enabled {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var value interface{}
+	if p != nil {
+		value = p
+	}
+	a.cache.set(hash, value, expires, a.now(), true)
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handler that echoes the principal id and the request body
+var echoPrincipal = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	p, _ := FromContext(r.Context())
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Write([]byte(p.ID + ":" + string(body)))
+})
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	lookups := 0
+	store := KeyStoreFunc(func(ctx context.Context, hash string) (*Principal, error) {
+		lookups++
+		if hash == HashAPIKey("valid-key") {
+			return &Principal{ID: "service-a", Scopes: []string{"payments:read"}}, nil
+		}
+		return nil, ErrKeyNotFound
+	})
+
+	authenticator := NewAPIKeyAuthenticator(APIKeyConfig{Store: store})
+	handler := authenticator.Middleware(RequireScope("payments:read")(echoPrincipal))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("valid-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "service-a:", rec.Body.String())
+
+	// cached
+	do("valid-key")
+	assert.Equal(t, 1, lookups)
+
+	// invalid keys are cached too
+	assert.Equal(t, http.StatusUnauthorized, do("invalid-key").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("invalid-key").Code)
+	assert.Equal(t, 2, lookups)
+
+	assert.Equal(t, http.StatusUnauthorized, do("").Code)
+
+	// cache expiration
+	authenticator.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	do("valid-key")
+	assert.Equal(t, 3, lookups)
+}
+
+func TestRequireScope(t *testing.T) {
+	store := KeyStoreFunc(func(ctx context.Context, hash string) (*Principal, error) {
+		return &Principal{ID: "service-b"}, nil
+	})
+
+	handler := NewAPIKeyAuthenticator(APIKeyConfig{Store: store}).Middleware(RequireScope("payments:write")(echoPrincipal))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	secret := []byte("s3cr3t")
+	secrets := func(ctx context.Context, keyID string) ([]byte, *Principal, error) {
+		if keyID == "acquirer" {
+			return secret, &Principal{ID: keyID}, nil
+		}
+		return nil, nil, ErrKeyNotFound
+	}
+
+	verifier := NewSignatureVerifier(SignatureConfig{Secrets: secrets})
+	handler := verifier.Middleware(echoPrincipal)
+
+	newRequest := func(keyID, nonce, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/payments?country=UY", bytes.NewReader([]byte(body)))
+		SignRequest(req, keyID, secret, nonce, []byte(body))
+		return req
+	}
+
+	// valid; body is still readable by the handler
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest("acquirer", "n1", `{"amount":10}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `acquirer:{"amount":10}`, rec.Body.String())
+
+	// replayed nonce
+	_, err := verifier.Verify(newRequest("acquirer", "n1", `{"amount":10}`))
+	assert.Equal(t, ErrReplayedNonce, err)
+
+	// tampered body
+	req := newRequest("acquirer", "n2", `{"amount":10}`)
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"amount":1000}`)))
+	_, err = verifier.Verify(req)
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	// unknown key
+	_, err = verifier.Verify(newRequest("unknown", "n3", ""))
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	// old timestamp
+	verifier.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	_, err = verifier.Verify(newRequest("acquirer", "n4", ""))
+	assert.Equal(t, ErrExpiredTimestamp, err)
+
+	// missing headers
+	_, err = verifier.Verify(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrMissingCredentials, err)
+}
+
+func TestAPIKeyCache(t *testing.T) {
+	store := KeyStoreFunc(func(ctx context.Context, hash string) (*Principal, error) {
+		return nil, ErrKeyNotFound
+	})
+	authenticator := NewAPIKeyAuthenticator(APIKeyConfig{Store: store, CacheSize: 3})
+
+	// random invalid keys don't grow the cache beyond its size
+	for _, key := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		_, err := authenticator.Authenticate(context.Background(), key)
+		assert.Equal(t, ErrInvalidCredentials, err)
+	}
+	assert.Equal(t, 3, authenticator.cache.len())
+
+	_, cached := authenticator.cache.get(HashAPIKey("k1"), time.Now())
+	assert.False(t, cached)
+	_, cached = authenticator.cache.get(HashAPIKey("k5"), time.Now())
+	assert.True(t, cached)
+
+	// expired entries are removed on the next insert
+	authenticator.now = func() time.Time { return time.Now().Add(time.Minute) }
+	authenticator.Authenticate(context.Background(), "k6")
+	assert.Equal(t, 1, authenticator.cache.len())
+}
+
+func TestAPIKeyStoreFailure(t *testing.T) {
+	failure := errors.New("dial tcp 10.0.0.5:3306: connection refused")
+	store := KeyStoreFunc(func(ctx context.Context, hash string) (*Principal, error) {
+		return nil, failure
+	})
+	authenticator := NewAPIKeyAuthenticator(APIKeyConfig{Store: store})
+
+	_, err := authenticator.Authenticate(context.Background(), "key")
+	assert.True(t, errors.Is(err, failure))
+
+	// the error isn't exposed to the caller
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "key")
+	rec := httptest.NewRecorder()
+	authenticator.Middleware(echoPrincipal).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.5")
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	store := NewMemoryNonceStore(2)
+	ctx := context.Background()
+
+	seen, err := store.Seen(ctx, "n1", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, seen)
+
+	seen, _ = store.Seen(ctx, "n1", time.Minute)
+	assert.True(t, seen)
+
+	// live nonces aren't evicted
+	store.Seen(ctx, "n2", time.Minute)
+	_, err = store.Seen(ctx, "n3", time.Minute)
+	assert.Equal(t, ErrTooManyNonces, err)
+
+	seen, _ = store.Seen(ctx, "n1", time.Minute)
+	assert.True(t, seen)
+
+	// but expired ones are
+	store = NewMemoryNonceStore(2)
+	store.Seen(ctx, "n1", -time.Second)
+	store.Seen(ctx, "n2", -time.Second)
+	_, err = store.Seen(ctx, "n3", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.nonces.len())
+}
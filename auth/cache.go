@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"container/list"
+	"time"
+)
+
+// bounded map of expiring entries kept in insertion order, so the expired ones are removed
+// from the front of the list without scanning the whole map. It isn't safe for concurrent use.
+type expiringMap struct {
+	maxSize int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type expiringEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newExpiringMap(maxSize int) *expiringMap {
+	return &expiringMap{maxSize: maxSize, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// returns the value of a key that didn't expire
+func (m *expiringMap) get(key string, now time.Time) (value interface{}, found bool) {
+	if element, exists := m.entries[key]; exists {
+		if entry := element.Value.(*expiringEntry); now.Before(entry.expires) {
+			return entry.value, true
+		}
+	}
+	return nil, false
+}
+
+// adds or replaces an entry, after removing the expired ones. When the map is full, the
+// oldest entry is evicted if `evict` is set, otherwise the entry isn't added and false is returned.
+func (m *expiringMap) set(key string, value interface{}, expires, now time.Time, evict bool) bool {
+	m.delete(key)
+	m.removeExpired(now)
+
+	if m.order.Len() >= m.maxSize {
+		if !evict {
+			return false
+		}
+		m.delete(m.order.Front().Value.(*expiringEntry).key)
+	}
+
+	m.entries[key] = m.order.PushBack(&expiringEntry{key: key, value: value, expires: expires})
+	return true
+}
+
+func (m *expiringMap) delete(key string) {
+	if element, exists := m.entries[key]; exists {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+}
+
+func (m *expiringMap) len() int {
+	return m.order.Len()
+}
+
+// removes the expired entries from the front; entries with a longer TTL added before shorter
+// ones delay their removal, but the size is bounded anyway
+func (m *expiringMap) removeExpired(now time.Time) {
+	for front := m.order.Front(); front != nil; front = m.order.Front() {
+		entry := front.Value.(*expiringEntry)
+		if now.Before(entry.expires) {
+			return
+		}
+		m.delete(entry.key)
+	}
+}
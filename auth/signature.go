@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Signature headers
+const (
+	HeaderKeyID     = "X-Key-Id"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+// SecretProvider returns the shared secret and the principal associated to a key id;
+// ErrKeyNotFound must be returned when the key id doesn't exist
+type SecretProvider func(ctx context.Context, keyID string) (secret []byte, p *Principal, err error)
+
+// NonceStore keeps the nonces already used. `Seen` must atomically check and store the
+// nonce, returning true if it was already used.
+type NonceStore interface {
+	Seen(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// SignatureConfig holds the request signature verification configuration
+type SignatureConfig struct {
+	Secrets SecretProvider
+
+	// Nonces used to prevent replays (default: in-memory store with the default size, only valid
+	// for a single instance)
+	Nonces NonceStore
+
+	// MaxSkew is the maximum allowed difference between the request timestamp and the
+	// server time (default 5 minutes)
+	MaxSkew time.Duration
+
+	// MaxBodySize is the maximum body size read to verify the signature (default 10MB)
+	MaxBodySize int64
+
+	// ErrorHandler writes the response when authentication fails (default 401, or 500 without
+	// details when the store fails)
+	ErrorHandler ErrorHandler
+}
+
+// SignatureVerifier validates HMAC-SHA256 request signatures. The signature is computed over:
+//
+//	METHOD \n PATH?QUERY \n TIMESTAMP \n NONCE \n hex(SHA-256(BODY))
+//
+// using the secret associated to the key id, and sent hex encoded in the X-Signature header
+// along with X-Key-Id, X-Timestamp (unix seconds) and X-Nonce. See `SignRequest`.
+type SignatureVerifier struct {
+	config SignatureConfig
+
+	// used for testing
+	now func() time.Time
+}
+
+// NewSignatureVerifier creates a new signature verifier
+func NewSignatureVerifier(config SignatureConfig) *SignatureVerifier {
+	if config.Nonces == nil {
+		config.Nonces = NewMemoryNonceStore(0)
+	}
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = 5 * time.Minute
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 10 << 20
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = defaultErrorHandler
+	}
+
+	return &SignatureVerifier{config: config, now: time.Now}
+}
+
+// Verify checks the request signature and returns the principal that signed it. The body is
+// read and replaced, so it's still available for the handlers. Failures of the secret provider
+// and the nonce store are wrapped, so they can be told apart from the authentication errors.
+func (v *SignatureVerifier) Verify(r *http.Request) (p *Principal, err error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	// check timestamp
+	ts, convErr := strconv.ParseInt(timestamp, 10, 64)
+	if convErr != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if skew := v.now().Sub(time.Unix(ts, 0)); skew > v.config.MaxSkew || skew < -v.config.MaxSkew {
+		return nil, ErrExpiredTimestamp
+	}
+
+	var secret []byte
+	if secret, p, err = v.config.Secrets(r.Context(), keyID); err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("looking up the secret: %w", err)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		// bodies over the limit can't be verified
+		if body, err = ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, v.config.MaxBodySize)); err != nil {
+			return nil, ErrInvalidCredentials
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := ComputeSignature(secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// the nonce is checked after the signature, so unsigned requests can't burn nonces;
+	// it's kept twice the allowed skew, which covers the whole acceptance window
+	var seen bool
+	if seen, err = v.config.Nonces.Seen(r.Context(), keyID+":"+nonce, 2*v.config.MaxSkew); err != nil {
+		return nil, fmt.Errorf("checking the nonce: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedNonce
+	}
+
+	return p, nil
+}
+
+// Middleware verifies the request signature and adds the principal to its context
+func (v *SignatureVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := v.Verify(r)
+		if err != nil {
+			v.config.ErrorHandler(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+	})
+}
+
+// ComputeSignature returns the hex encoded HMAC-SHA256 signature of a request
+func ComputeSignature(secret []byte, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + requestURI + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest adds the signature headers to an outgoing request; `body` must be the
+// same content sent in the request body
+func SignRequest(r *http.Request, keyID string, secret []byte, nonce string, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	r.Header.Set(HeaderKeyID, keyID)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, ComputeSignature(secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+}
+
+// MemoryNonceStore is an in-process `NonceStore`; services running several instances
+// should use a shared store (ie: Redis SET NX)
+type MemoryNonceStore struct {
+	mutex  sync.Mutex
+	nonces *expiringMap
+}
+
+// NewMemoryNonceStore creates an in-memory nonce store that keeps up to `maxSize` nonces
+// (default 100000 when <= 0)
+func NewMemoryNonceStore(maxSize int) *MemoryNonceStore {
+	if maxSize <= 0 {
+		maxSize = 100000
+	}
+	return &MemoryNonceStore{nonces: newExpiringMap(maxSize)}
+}
+
+// Seen checks and stores the nonce. Nonces aren't evicted before they expire, since that
+// would allow replays, so ErrTooManyNonces is returned when the store is full.
+func (m *MemoryNonceStore) Seen(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	if _, exists := m.nonces.get(nonce, now); exists {
+		return true, nil
+	}
+
+	if !m.nonces.set(nonce, nil, now.Add(ttl), now, false) {
+		return false, ErrTooManyNonces
+	}
+	return false, nil
+}
@@ -0,0 +1,343 @@
+// Package batch runs chunked jobs: items are read in chunks, processed one by one and written
+// per chunk, with bounded concurrency, retries, skip policies and checkpoints to resume a job
+// that was interrupted.
+//
+//	job := batch.NewJob("settlement-2020-01-15", batch.Config{ChunkSize: 500, Concurrency: 4, MaxRetries: 3}, reader, processor, writer)
+//	progress, err := job.Run(ctx)
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Batch errors
+var (
+	ErrSkipLimitExceeded = errors.New("skip limit exceeded")
+	ErrAlreadyRunning    = errors.New("job is already running")
+)
+
+// Reader reads a chunk of at most `limit` items starting at `offset`; an empty chunk means there
+// are no more items
+type Reader interface {
+	Read(ctx context.Context, offset int64, limit int) (items []interface{}, err error)
+}
+
+// ReaderFunc is an adapter to allow the use of ordinary functions as readers
+type ReaderFunc func(ctx context.Context, offset int64, limit int) ([]interface{}, error)
+
+// Read calls f(ctx, offset, limit)
+func (f ReaderFunc) Read(ctx context.Context, offset int64, limit int) ([]interface{}, error) {
+	return f(ctx, offset, limit)
+}
+
+// Processor transforms an item; returning a nil result filters the item out
+type Processor interface {
+	Process(ctx context.Context, item interface{}) (result interface{}, err error)
+}
+
+// ProcessorFunc is an adapter to allow the use of ordinary functions as processors
+type ProcessorFunc func(ctx context.Context, item interface{}) (interface{}, error)
+
+// Process calls f(ctx, item)
+func (f ProcessorFunc) Process(ctx context.Context, item interface{}) (interface{}, error) {
+	return f(ctx, item)
+}
+
+// Writer writes the processed items of a chunk
+type Writer interface {
+	Write(ctx context.Context, items []interface{}) error
+}
+
+// WriterFunc is an adapter to allow the use of ordinary functions as writers
+type WriterFunc func(ctx context.Context, items []interface{}) error
+
+// Write calls f(ctx, items)
+func (f WriterFunc) Write(ctx context.Context, items []interface{}) error {
+	return f(ctx, items)
+}
+
+// Config holds the job configuration
+type Config struct {
+	// ChunkSize is the amount of items read, processed and written together (default 100)
+	ChunkSize int
+
+	// Concurrency is the amount of chunks processed at the same time (default 1)
+	Concurrency int
+
+	// MaxRetries is the amount of times a failed chunk is retried before failing the job
+	MaxRetries int
+
+	// RetryBackoff is the time to wait before retrying a chunk; it doubles on each retry (default 1s)
+	RetryBackoff time.Duration
+
+	// Skip decides if an item that failed processing can be skipped; when nil, any processing
+	// error fails the chunk
+	Skip func(item interface{}, err error) bool
+
+	// SkipLimit is the max amount of skipped items in the job (0 means no limit)
+	SkipLimit int64
+
+	// Checkpoints stores the job progress to resume it; when nil, jobs always start from the beginning
+	Checkpoints CheckpointStore
+
+	// OnProgress is invoked after each chunk is written
+	OnProgress func(progress Progress)
+}
+
+// ChunkError is returned when a chunk fails after all the retries
+type ChunkError struct {
+	Offset   int64
+	Attempts int
+	Err      error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk at offset %d failed after %d attempts: %s", e.Offset, e.Attempts, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// Job is a chunked job
+type Job struct {
+	name      string
+	config    Config
+	reader    Reader
+	processor Processor
+	writer    Writer
+
+	running  bool
+	progress *tracker
+	mutex    sync.Mutex
+}
+
+// NewJob creates a job; the name identifies its checkpoints
+func NewJob(name string, config Config, reader Reader, processor Processor, writer Writer) *Job {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 100
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+
+	return &Job{name: name, config: config, reader: reader, processor: processor, writer: writer}
+}
+
+// Name returns the job name
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Progress returns the progress of the current (or last) run
+func (j *Job) Progress() Progress {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.progress == nil {
+		return Progress{}
+	}
+	return j.progress.snapshot()
+}
+
+// Run executes the job until all the items are written, a chunk fails or the context is
+// cancelled. When a checkpoint store is configured the job resumes from the last checkpoint,
+// and the checkpoint is cleared once the job completes.
+func (j *Job) Run(ctx context.Context) (progress Progress, err error) {
+	j.mutex.Lock()
+	if j.running {
+		j.mutex.Unlock()
+		return Progress{}, ErrAlreadyRunning
+	}
+
+	var offset int64
+	if j.config.Checkpoints != nil {
+		if offset, err = j.config.Checkpoints.Load(ctx, j.name); err != nil {
+			j.mutex.Unlock()
+			return
+		}
+	}
+
+	j.running = true
+	j.progress = newTracker(offset)
+	j.mutex.Unlock()
+
+	defer func() {
+		j.mutex.Lock()
+		j.running = false
+		j.mutex.Unlock()
+	}()
+
+	err = j.run(ctx, offset)
+	if err == nil && j.config.Checkpoints != nil {
+		err = j.config.Checkpoints.Clear(ctx, j.name)
+	}
+
+	progress = j.progress.finish()
+	return
+}
+
+// a chunk read from the source
+type chunk struct {
+	offset int64
+	items  []interface{}
+}
+
+func (j *Job) run(ctx context.Context, offset int64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := make(chan chunk)
+	checkpoints := newWatermark(offset)
+
+	var (
+		wg       sync.WaitGroup
+		errMutex sync.Mutex
+		firstErr error
+	)
+
+	fail := func(err error) {
+		errMutex.Lock()
+		defer errMutex.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for i := 0; i < j.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				if err := j.runChunk(ctx, c); err != nil {
+					fail(err)
+					continue
+				}
+
+				next := c.offset + int64(len(c.items))
+				if watermark, advanced := checkpoints.complete(c.offset, next); advanced && j.config.Checkpoints != nil {
+					err := checkpoints.save(watermark, func(offset int64) error {
+						return j.config.Checkpoints.Save(ctx, j.name, offset)
+					})
+					if err != nil {
+						fail(err)
+					}
+				}
+
+				if j.config.OnProgress != nil {
+					j.config.OnProgress(j.progress.snapshot())
+				}
+			}
+		}()
+	}
+
+	// read the chunks sequentially and dispatch them to the workers
+	for ctx.Err() == nil {
+		items, err := j.reader.Read(ctx, offset, j.config.ChunkSize)
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		if len(items) == 0 {
+			break
+		}
+
+		j.progress.add(&j.progress.read, int64(len(items)))
+
+		select {
+		case chunks <- chunk{offset: offset, items: items}:
+		case <-ctx.Done():
+		}
+		offset += int64(len(items))
+	}
+
+	close(chunks)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// processes and writes a chunk, retrying it on failure
+func (j *Job) runChunk(ctx context.Context, c chunk) (err error) {
+	backoff := j.config.RetryBackoff
+	attempts := 0
+
+	for {
+		attempts++
+
+		var results []interface{}
+		var skipped int64
+		if results, skipped, err = j.processChunk(ctx, c); err == nil {
+			if err = j.writer.Write(ctx, results); err == nil {
+				j.progress.add(&j.progress.processed, int64(len(c.items))-skipped)
+				j.progress.add(&j.progress.written, int64(len(results)))
+				j.progress.add(&j.progress.skipped, skipped)
+				j.progress.add(&j.progress.chunks, 1)
+				return nil
+			}
+		}
+
+		if err == ErrSkipLimitExceeded || ctx.Err() != nil || attempts > j.config.MaxRetries {
+			break
+		}
+
+		j.progress.add(&j.progress.retries, 1)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	j.progress.add(&j.progress.failed, 1)
+	return &ChunkError{Offset: c.offset, Attempts: attempts, Err: err}
+}
+
+// processes the items of a chunk, skipping the failed ones allowed by the skip policy
+func (j *Job) processChunk(ctx context.Context, c chunk) (results []interface{}, skipped int64, err error) {
+	results = make([]interface{}, 0, len(c.items))
+
+	for _, item := range c.items {
+		result, processErr := j.processor.Process(ctx, item)
+		if processErr != nil {
+			if j.config.Skip == nil || !j.config.Skip(item, processErr) {
+				return nil, 0, processErr
+			}
+
+			if j.config.SkipLimit > 0 && j.progress.get(&j.progress.skipped)+skipped >= j.config.SkipLimit {
+				return nil, 0, ErrSkipLimitExceeded
+			}
+
+			skipped++
+			continue
+		}
+
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	return
+}
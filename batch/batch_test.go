@@ -0,0 +1,231 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// reads the numbers from 0 to n-1
+func numbersReader(n int) Reader {
+	return ReaderFunc(func(ctx context.Context, offset int64, limit int) ([]interface{}, error) {
+		items := make([]interface{}, 0, limit)
+		for i := offset; i < int64(n) && len(items) < limit; i++ {
+			items = append(items, int(i))
+		}
+		return items, nil
+	})
+}
+
+type sliceWriter struct {
+	mutex sync.Mutex
+	items []int
+}
+
+func (w *sliceWriter) Write(ctx context.Context, items []interface{}) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, item := range items {
+		w.items = append(w.items, item.(int))
+	}
+	return nil
+}
+
+func (w *sliceWriter) sorted() []int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	sort.Ints(w.items)
+	return w.items
+}
+
+var double = ProcessorFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+	return item.(int) * 2, nil
+})
+
+func TestRun(t *testing.T) {
+	writer := new(sliceWriter)
+	onlyEven := ProcessorFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		if item.(int)%2 != 0 {
+			return nil, nil
+		}
+		return item, nil
+	})
+
+	var progressCalls int32
+	job := NewJob("even", Config{ChunkSize: 3, Concurrency: 4, OnProgress: func(p Progress) {
+		atomic.AddInt32(&progressCalls, 1)
+	}}, numbersReader(10), onlyEven, writer)
+
+	progress, err := job.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 2, 4, 6, 8}, writer.sorted())
+	assert.Equal(t, int64(10), progress.Read)
+	assert.Equal(t, int64(10), progress.Processed)
+	assert.Equal(t, int64(5), progress.Written)
+	assert.Equal(t, int64(4), progress.Chunks)
+	assert.True(t, progress.Finished)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&progressCalls))
+}
+
+func TestProgressWhileRunning(t *testing.T) {
+	slow := WriterFunc(func(ctx context.Context, items []interface{}) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	job := NewJob("progress", Config{ChunkSize: 2, Concurrency: 2}, numbersReader(20), double, slow)
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		close(started)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				job.Progress()
+			}
+		}
+	}()
+
+	<-started
+	progress, err := job.Run(context.Background())
+	done <- struct{}{}
+
+	assert.Nil(t, err)
+	assert.True(t, progress.Finished)
+	assert.True(t, job.Progress().Finished)
+	assert.Equal(t, progress.Elapsed, job.Progress().Elapsed)
+}
+
+func TestRunSkip(t *testing.T) {
+	invalid := errors.New("invalid item")
+	processor := ProcessorFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		if item.(int)%4 == 0 {
+			return nil, invalid
+		}
+		return item, nil
+	})
+	skip := func(item interface{}, err error) bool { return err == invalid }
+
+	writer := new(sliceWriter)
+	progress, err := NewJob("skip", Config{ChunkSize: 5, Skip: skip}, numbersReader(10), processor, writer).Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3, 5, 6, 7, 9}, writer.sorted())
+	assert.Equal(t, int64(3), progress.Skipped)
+
+	_, err = NewJob("skip", Config{ChunkSize: 5, Skip: skip, SkipLimit: 2}, numbersReader(10), processor, new(sliceWriter)).Run(context.Background())
+	assert.True(t, errors.Is(err, ErrSkipLimitExceeded))
+}
+
+func TestRunRetry(t *testing.T) {
+	var calls int32
+	flaky := WriterFunc(func(ctx context.Context, items []interface{}) error {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return errors.New("deadlock found when trying to get lock")
+		}
+		return nil
+	})
+
+	progress, err := NewJob("retry", Config{ChunkSize: 10, MaxRetries: 2, RetryBackoff: time.Millisecond}, numbersReader(5), double, flaky).Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), progress.Retries)
+	assert.Equal(t, int64(5), progress.Written)
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = NewJob("retry", Config{ChunkSize: 10, MaxRetries: 1, RetryBackoff: time.Millisecond}, numbersReader(5), double, flaky).Run(context.Background())
+	if chunkErr, ok := err.(*ChunkError); assert.True(t, ok) {
+		assert.Equal(t, int64(0), chunkErr.Offset)
+		assert.Equal(t, 2, chunkErr.Attempts)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	checkpoints := NewMemoryCheckpointStore()
+	writer := new(sliceWriter)
+
+	failing := true
+	processor := ProcessorFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		if failing && item.(int) == 7 {
+			return nil, errors.New("partner timeout")
+		}
+		return item, nil
+	})
+
+	job := NewJob("resume", Config{ChunkSize: 3, Checkpoints: checkpoints}, numbersReader(10), processor, writer)
+	_, err := job.Run(context.Background())
+	assert.NotNil(t, err)
+
+	offset, _ := checkpoints.Load(context.Background(), "resume")
+	assert.Equal(t, int64(6), offset)
+
+	failing = false
+	progress, err := job.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), progress.StartOffset)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, writer.sorted())
+
+	// completed jobs clear their checkpoint
+	offset, _ = checkpoints.Load(context.Background(), "resume")
+	assert.Equal(t, int64(0), offset)
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := WriterFunc(func(ctx context.Context, items []interface{}) error {
+		cancel()
+		return nil
+	})
+
+	_, err := NewJob("cancel", Config{ChunkSize: 1}, numbersReader(100), double, writer).Run(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestWatermark(t *testing.T) {
+	w := newWatermark(0)
+
+	offset, advanced := w.complete(10, 20)
+	assert.False(t, advanced)
+	assert.Equal(t, int64(0), offset)
+
+	offset, advanced = w.complete(0, 10)
+	assert.True(t, advanced)
+	assert.Equal(t, int64(20), offset)
+}
+
+func TestSQLCheckpointStore(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	if _, err = db.Exec("CREATE TABLE batch_checkpoints (job VARCHAR(100) PRIMARY KEY, offset_value BIGINT, updated_at DATETIME)"); err != nil {
+		t.Fatalf("Error creating table: %s", err.Error())
+	}
+
+	ctx := context.Background()
+	store := NewSQLCheckpointStore(db, "batch_checkpoints")
+
+	offset, err := store.Load(ctx, "settlement")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), offset)
+
+	assert.Nil(t, store.Save(ctx, "settlement", 500))
+	assert.Nil(t, store.Save(ctx, "settlement", 1000))
+	offset, _ = store.Load(ctx, "settlement")
+	assert.Equal(t, int64(1000), offset)
+
+	assert.Nil(t, store.Clear(ctx, "settlement"))
+	offset, _ = store.Load(ctx, "settlement")
+	assert.Equal(t, int64(0), offset)
+}
@@ -0,0 +1,149 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CheckpointStore persists the offset up to which a job was completed
+type CheckpointStore interface {
+	// Load returns the saved offset, or 0 when the job has no checkpoint
+	Load(ctx context.Context, job string) (offset int64, err error)
+	Save(ctx context.Context, job string, offset int64) error
+	Clear(ctx context.Context, job string) error
+}
+
+// MemoryCheckpointStore keeps the checkpoints in memory; useful for tests and for retrying a
+// job within the same process
+type MemoryCheckpointStore struct {
+	mutex       sync.Mutex
+	checkpoints map[string]int64
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string]int64)}
+}
+
+// Load returns the saved offset
+func (s *MemoryCheckpointStore) Load(ctx context.Context, job string) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.checkpoints[job], nil
+}
+
+// Save stores the offset
+func (s *MemoryCheckpointStore) Save(ctx context.Context, job string, offset int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.checkpoints[job] = offset
+	return nil
+}
+
+// Clear removes the checkpoint
+func (s *MemoryCheckpointStore) Clear(ctx context.Context, job string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.checkpoints, job)
+	return nil
+}
+
+// SQLCheckpointStore keeps the checkpoints in a database table with the columns
+// `job` (varchar, primary key), `offset_value` (bigint) and `updated_at` (datetime)
+type SQLCheckpointStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLCheckpointStore creates a store over the indicated table
+func NewSQLCheckpointStore(db *sql.DB, table string) *SQLCheckpointStore {
+	return &SQLCheckpointStore{db: db, table: table}
+}
+
+// Load returns the saved offset
+func (s *SQLCheckpointStore) Load(ctx context.Context, job string) (offset int64, err error) {
+	query := fmt.Sprintf("SELECT offset_value FROM %s WHERE job = ?", s.table)
+	if err = s.db.QueryRowContext(ctx, query, job).Scan(&offset); err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return
+}
+
+// Save stores the offset
+func (s *SQLCheckpointStore) Save(ctx context.Context, job string, offset int64) error {
+	now := time.Now().UTC()
+
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET offset_value = ?, updated_at = ? WHERE job = ?", s.table), offset, now, job)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (job, offset_value, updated_at) VALUES (?, ?, ?)", s.table), job, offset, now)
+	return err
+}
+
+// Clear removes the checkpoint
+func (s *SQLCheckpointStore) Clear(ctx context.Context, job string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE job = ?", s.table), job)
+	return err
+}
+
+// watermark tracks the completed chunks to compute the offset up to which all the chunks are
+// completed, since concurrent chunks can finish out of order
+type watermark struct {
+	mutex    sync.Mutex
+	offset   int64
+	finished map[int64]int64 // chunks completed beyond the watermark: offset -> next offset
+
+	saveMutex sync.Mutex
+	saved     int64
+}
+
+func newWatermark(offset int64) *watermark {
+	return &watermark{offset: offset, saved: offset, finished: make(map[int64]int64)}
+}
+
+// marks the chunk [offset, next) as completed; it returns the new watermark and whether it advanced
+func (w *watermark) complete(offset, next int64) (int64, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.finished[offset] = next
+
+	advanced := false
+	for {
+		next, found := w.finished[w.offset]
+		if !found {
+			break
+		}
+		delete(w.finished, w.offset)
+		w.offset = next
+		advanced = true
+	}
+
+	return w.offset, advanced
+}
+
+// saves the offset unless a greater one was already saved by another worker
+func (w *watermark) save(offset int64, save func(offset int64) error) error {
+	w.saveMutex.Lock()
+	defer w.saveMutex.Unlock()
+
+	if offset <= w.saved {
+		return nil
+	}
+
+	if err := save(offset); err != nil {
+		return err
+	}
+
+	w.saved = offset
+	return nil
+}
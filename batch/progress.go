@@ -0,0 +1,82 @@
+package batch
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Progress holds the job metrics
+type Progress struct {
+	StartOffset int64         `json:"start_offset"`
+	Read        int64         `json:"read"`
+	Processed   int64         `json:"processed"`
+	Written     int64         `json:"written"`
+	Skipped     int64         `json:"skipped"`
+	Chunks      int64         `json:"chunks"`
+	Retries     int64         `json:"retries"`
+	Failed      int64         `json:"failed_chunks"`
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	Finished    bool          `json:"finished"`
+}
+
+// ItemsPerSecond returns the processing throughput
+func (p Progress) ItemsPerSecond() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Processed) / p.Elapsed.Seconds()
+}
+
+// tracks the metrics of a run, updated concurrently by the workers
+type tracker struct {
+	startOffset int64
+	startedAt   time.Time
+	finishedAt  int64 // unix nanoseconds; read with snapshot while the run finishes
+
+	read      int64
+	processed int64
+	written   int64
+	skipped   int64
+	chunks    int64
+	retries   int64
+	failed    int64
+}
+
+func newTracker(startOffset int64) *tracker {
+	return &tracker{startOffset: startOffset, startedAt: time.Now()}
+}
+
+func (t *tracker) add(counter *int64, delta int64) {
+	atomic.AddInt64(counter, delta)
+}
+
+func (t *tracker) get(counter *int64) int64 {
+	return atomic.LoadInt64(counter)
+}
+
+// marks the run as finished and returns the final metrics
+func (t *tracker) finish() Progress {
+	atomic.StoreInt64(&t.finishedAt, time.Now().UnixNano())
+	return t.snapshot()
+}
+
+func (t *tracker) snapshot() Progress {
+	p := Progress{
+		StartOffset: t.startOffset,
+		Read:        t.get(&t.read),
+		Processed:   t.get(&t.processed),
+		Written:     t.get(&t.written),
+		Skipped:     t.get(&t.skipped),
+		Chunks:      t.get(&t.chunks),
+		Retries:     t.get(&t.retries),
+		Failed:      t.get(&t.failed),
+		Elapsed:     time.Since(t.startedAt),
+	}
+
+	if finishedAt := t.get(&t.finishedAt); finishedAt != 0 {
+		p.Elapsed = time.Unix(0, finishedAt).Sub(t.startedAt)
+		p.Finished = true
+	}
+
+	return p
+}
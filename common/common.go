@@ -20,6 +20,9 @@ var (
 	// email validation regular expression
 	emailRegEx = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,4}$`)
 
+	// E.164 phone number regular expression (+ followed by up to 15 digits)
+	e164RegEx = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
 	randomGenerator = rand.New(rand.NewSource(time.Now().Unix()))
 )
 
@@ -28,6 +31,11 @@ func IsEmailAddress(str string) bool {
 	return emailRegEx.MatchString(str)
 }
 
+// IsE164PhoneNumber returns true if str is a phone number in E.164 format (ie: +59899123456)
+func IsE164PhoneNumber(str string) bool {
+	return e164RegEx.MatchString(str)
+}
+
 // UpdateStructFields() errors
 var (
 	ErrNotStruct = errors.New("destination must by struct or a pointer to struct")
@@ -197,3 +205,92 @@ func GetNonNullFields(i interface{}, tagName string) (fields []string) {
 
 	return
 }
+
+// Diff() errors
+var (
+	ErrDiffTypeMismatch = errors.New("values to diff must be structs (or pointers to structs) of the same type")
+)
+
+// Change holds the previous and the new value of a field
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the fields whose values are different in both structs, by field name or by
+// the name in the `tagName` tag when it's not empty (fields tagged with "-" are ignored).
+// Any of the values can be nil (ie: on creation or deletion), in which case all the fields
+// of the other one are returned.
+func Diff(before, after interface{}, tagName string) (changes map[string]Change, err error) {
+	beforeVal, afterVal := structValue(before), structValue(after)
+
+	if !beforeVal.IsValid() && !afterVal.IsValid() {
+		return nil, ErrDiffTypeMismatch
+	}
+
+	structType := beforeVal
+	if !structType.IsValid() {
+		structType = afterVal
+	}
+
+	if structType.Kind() != reflect.Struct || (beforeVal.IsValid() && afterVal.IsValid() && beforeVal.Type() != afterVal.Type()) {
+		return nil, ErrDiffTypeMismatch
+	}
+
+	changes = make(map[string]Change)
+	for f := 0; f < structType.NumField(); f++ {
+		field := structType.Type().Field(f)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		name := field.Name
+		if tagName != "" {
+			tag := strings.Split(field.Tag.Get(tagName), ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		var from, to interface{}
+		if beforeVal.IsValid() {
+			from = derefValue(beforeVal.Field(f))
+		}
+		if afterVal.IsValid() {
+			to = derefValue(afterVal.Field(f))
+		}
+
+		if !reflect.DeepEqual(from, to) {
+			changes[name] = Change{From: from, To: to}
+		}
+	}
+
+	return
+}
+
+// returns the struct value of v (dereferencing pointers), or an invalid value if v is nil
+func structValue(v interface{}) reflect.Value {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// returns the value of the field, or nil for nil pointers
+func derefValue(field reflect.Value) interface{} {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	return field.Interface()
+}
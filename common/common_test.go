@@ -36,6 +36,23 @@ func TestIsEmailAddress(t *testing.T) {
 	}
 }
 
+// test for valid E.164 phone numbers
+func TestIsE164PhoneNumber(t *testing.T) {
+	valid := []string{"+59899123456", "+5511987654321", "+14155552671"}
+	for _, n := range valid {
+		if !IsE164PhoneNumber(n) {
+			t.Errorf("'%s' is a valid E.164 phone number", n)
+		}
+	}
+
+	invalid := []string{"59899123456", "+059899123456", "+598 99 123 456", "+5989912345678901", "+", ""}
+	for _, n := range invalid {
+		if IsE164PhoneNumber(n) {
+			t.Errorf("'%s' is not a valid E.164 phone number", n)
+		}
+	}
+}
+
 // test for UpdateStructFields() function
 func TestUpdateStructFields(t *testing.T) {
 
@@ -132,3 +149,35 @@ func TestGetNonNullFields(t *testing.T) {
 	}
 
 }
+
+func TestDiff(t *testing.T) {
+
+	type account struct {
+		ID       int64   `json:"id"`
+		Status   string  `json:"status"`
+		Limit    *int64  `json:"limit"`
+		Password string  `json:"-"`
+		Balance  float64 `json:"balance"`
+		internal string
+	}
+
+	limit := int64(500)
+	before := &account{ID: 1, Status: "active", Password: "a", Balance: 10, internal: "x"}
+	after := &account{ID: 1, Status: "blocked", Limit: &limit, Password: "b", Balance: 10, internal: "y"}
+
+	changes, err := Diff(before, after, "json")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]Change{
+		"status": {From: "active", To: "blocked"},
+		"limit":  {From: nil, To: int64(500)},
+	}, changes)
+
+	// by field name, on creation
+	changes, _ = Diff(nil, after, "")
+	assert.Len(t, changes, 5)
+	assert.Equal(t, Change{From: nil, To: "b"}, changes["Password"])
+
+	if _, err = Diff(before, struct{ ID int64 }{}, ""); err != ErrDiffTypeMismatch {
+		t.Errorf("expected ErrDiffTypeMismatch, got %v", err)
+	}
+}
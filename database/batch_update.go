@@ -0,0 +1,136 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BuildBatchUpdateQuery returns a single parametrized UPDATE for all the items of objs (a slice
+// of structs or pointers to structs), using the field keyField to identify the rows:
+//
+//	UPDATE table SET col=CASE key WHEN ? THEN ? ... END,... WHERE key IN (?,...)
+//
+// Both keyField and fields are struct field names, like in the SET builders. Nil pointer values
+// are set to NULL; keys must be unique. Note that every item takes 2 arguments per field plus one,
+// so big slices should be split in chunks to stay below the driver placeholders limit. An empty
+// table is resolved from the items type (see TableName).
+//
+// It's only supported for MySQL and SQLite: Postgres types the THEN parameters as text, so
+// ErrUnsupportedDialect is returned when it's the default dialect.
+func BuildBatchUpdateQuery(table string, objs interface{}, keyField string, fields []string) (query string, args []interface{}, err error) {
+
+	if DefaultDialect().Name() == Postgres.Name() {
+		err = ErrUnsupportedDialect
+		return
+	}
+
+	if len(fields) == 0 {
+		err = ErrInvalidFieldList
+		return
+	}
+
+	objsVal := reflect.ValueOf(objs)
+	if objsVal.Kind() != reflect.Slice {
+		err = fmt.Errorf("invalid objs type '%s'", objsVal.Kind().String())
+		return
+	}
+
+	if objsVal.Len() == 0 {
+		err = ErrNoConditions
+		return
+	}
+
+	objType := objsVal.Type().Elem()
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	if objType.Kind() != reflect.Struct {
+		err = fmt.Errorf("invalid obj type '%s'", objType.Kind().String())
+		return
+	}
+
+	if table == "" {
+		if table, err = tableNameOf(objType); err != nil {
+			return
+		}
+	}
+
+	meta := getTypeMeta(objType)
+
+	keyMeta, exists := meta.fieldByName(keyField)
+	if !exists {
+		err = fmt.Errorf("invalid key field '%s'", keyField)
+		return
+	}
+	keyCol := keyMeta.column
+
+	columns := make([]string, len(fields))
+	fieldMetas := make([]*fieldMeta, len(fields))
+	for i, field := range fields {
+		var exists bool
+		if fieldMetas[i], exists = meta.fieldByName(field); !exists {
+			err = fmt.Errorf("invalid field '%s'", field)
+			return
+		}
+		columns[i] = fieldMetas[i].column
+	}
+
+	// collect keys and values of every item
+	keys := make([]interface{}, objsVal.Len())
+	values := make([][]interface{}, objsVal.Len())
+	seen := make(map[interface{}]bool, objsVal.Len())
+
+	for i := 0; i < objsVal.Len(); i++ {
+		itemVal := reflect.Indirect(objsVal.Index(i))
+		if !itemVal.IsValid() {
+			err = fmt.Errorf("item %d is nil", i)
+			return
+		}
+
+		keyVal := reflect.Indirect(itemVal.FieldByIndex(keyMeta.field.Index))
+		if !keyVal.IsValid() || !keyVal.Type().Comparable() {
+			err = fmt.Errorf("invalid key value for item %d", i)
+			return
+		}
+
+		if keys[i] = keyVal.Interface(); seen[keys[i]] {
+			err = fmt.Errorf("duplicated key '%v'", keys[i])
+			return
+		}
+		seen[keys[i]] = true
+
+		values[i] = make([]interface{}, len(fields))
+		for j, field := range fieldMetas {
+			if values[i][j], err = getFieldValue(field, itemVal.FieldByIndex(field.field.Index)); err != nil {
+				return
+			}
+		}
+	}
+
+	// prepare sql
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("UPDATE %s SET ", table))
+
+	for j, col := range columns {
+		if j > 0 {
+			buf.WriteString(",")
+		}
+
+		buf.WriteString(fmt.Sprintf("%s=CASE %s", col, keyCol))
+		for i := range keys {
+			buf.WriteString(" WHEN ? THEN ?")
+			args = append(args, keys[i], values[i][j])
+		}
+		buf.WriteString(" END")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	buf.WriteString(fmt.Sprintf(" WHERE %s IN (%s)", keyCol, placeholders))
+	args = append(args, keys...)
+
+	query = buf.String()
+	return
+}
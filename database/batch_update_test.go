@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for BuildBatchUpdateQuery()
+func TestBuildBatchUpdateQuery(t *testing.T) {
+
+	john, jane := "John", "Jane"
+	users := []*User{
+		{ID: 1, Name: &john, Active: true},
+		{ID: 2, Name: &jane},
+		{ID: 3},
+	}
+
+	query, args, err := BuildBatchUpdateQuery("users", users, "ID", []string{"Name", "Active"})
+	if err != nil {
+		t.Errorf("BuildBatchUpdateQuery() returned an error: %s", err.Error())
+		return
+	}
+
+	assert.Equal(t, "UPDATE users SET "+
+		"name=CASE id_user WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END,"+
+		"active=CASE id_user WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END "+
+		"WHERE id_user IN (?,?,?)", query)
+	assert.Equal(t, []interface{}{1, "John", 2, "Jane", 3, nil, 1, true, 2, false, 3, false, 1, 2, 3}, args)
+
+	// run it against a real database
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	db.Exec("CREATE TABLE users (id_user INTEGER PRIMARY KEY, name TEXT, active BOOLEAN)")
+	db.Exec("INSERT INTO users VALUES (1, 'a', 0), (2, 'b', 1), (3, 'c', 1), (4, 'd', 1)")
+
+	if _, err = db.Exec(query, args...); err != nil {
+		t.Fatalf("Error running batch update: %s", err.Error())
+	}
+
+	var name sql.NullString
+	var active bool
+	db.QueryRow("SELECT name, active FROM users WHERE id_user = 1").Scan(&name, &active)
+	assert.Equal(t, "John", name.String)
+	assert.True(t, active)
+
+	db.QueryRow("SELECT name, active FROM users WHERE id_user = 3").Scan(&name, &active)
+	assert.False(t, name.Valid)
+
+	db.QueryRow("SELECT name, active FROM users WHERE id_user = 4").Scan(&name, &active)
+	assert.Equal(t, "d", name.String)
+	assert.True(t, active)
+
+	// errors
+	_, _, err = BuildBatchUpdateQuery("users", []User{{ID: 1}, {ID: 1}}, "ID", []string{"Name"})
+	assert.Equal(t, "duplicated key '1'", err.Error())
+
+	_, _, err = BuildBatchUpdateQuery("users", []User{}, "ID", []string{"Name"})
+	assert.Equal(t, ErrNoConditions, err)
+
+	_, _, err = BuildBatchUpdateQuery("users", users, "ID", nil)
+	assert.Equal(t, ErrInvalidFieldList, err)
+
+	// the THEN parameters are typed as text in postgres
+	SetDefaultDialect(Postgres)
+	_, _, err = BuildBatchUpdateQuery("users", users, "ID", []string{"Name"})
+	SetDefaultDialect(MySQL)
+	assert.Equal(t, ErrUnsupportedDialect, err)
+
+	_, _, err = BuildBatchUpdateQuery("users", users, "Key", []string{"Name"})
+	assert.Equal(t, "invalid key field 'Key'", err.Error())
+
+	_, _, err = BuildBatchUpdateQuery("users", User{}, "ID", []string{"Name"})
+	if err == nil {
+		t.Errorf("BuildBatchUpdateQuery() should have returned an error")
+	}
+}
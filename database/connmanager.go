@@ -0,0 +1,491 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/astropay/go-tools/healthcheck"
+)
+
+// Connection manager errors
+var (
+	ErrPoolNotFound  = errors.New("no database pool found with the specified name")
+	ErrPoolExists    = errors.New("a database pool with the same name already exists")
+	ErrManagerClosed = errors.New("connection manager is closed")
+)
+
+// PoolConfig configures a database connection pool
+type PoolConfig struct {
+	Driver string
+	DSN    string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Replicas are the read replicas of the database, used by the read-only helpers (ie: Reader).
+	// They're managed as pools named "<name>/replica-<n>", starting at 1; their own replicas are ignored.
+	Replicas []PoolConfig
+}
+
+// ConnManagerConfig configures the connection manager
+type ConnManagerConfig struct {
+	// Pools to open, by name
+	Pools map[string]PoolConfig
+
+	// PingInterval is the time between the health pings of every pool; zero disables them
+	PingInterval time.Duration
+
+	// PingTimeout limits each ping (default 2s)
+	PingTimeout time.Duration
+
+	// OnHealthChange is called when a pool becomes healthy or unhealthy
+	OnHealthChange func(name string, health PoolHealth)
+
+	// PinReadsAfterWrite routes the reads to the primary after a write in the same context (see
+	// TrackWrites), so they don't miss it because of the replication lag
+	PinReadsAfterWrite bool
+}
+
+// PoolHealth is the health of a pool after the last ping, with its connection stats
+type PoolHealth struct {
+	Healthy         bool      `json:"healthy"`
+	Error           string    `json:"error,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+	OpenConnections int       `json:"open_connections"`
+	InUse           int       `json:"in_use"`
+	Idle            int       `json:"idle"`
+	WaitCount       int64     `json:"wait_count"`
+}
+
+type pool struct {
+	db       *sql.DB
+	health   PoolHealth
+	replicas []*pool
+	next     uint32
+}
+
+type contextKey int
+
+const (
+	pinPrimaryKey contextKey = iota
+	writeTrackerKey
+)
+
+// PinPrimary returns a context where all the reads are routed to the primary
+func PinPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinPrimaryKey, true)
+}
+
+// TrackWrites returns a context that records the writes made through the manager (ie: with
+// ExecContext or Writer), so the following reads in it are routed to the primary when
+// PinReadsAfterWrite is set. It's meant to be created once per request or unit of work.
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeTrackerKey, new(int32))
+}
+
+func markWrite(ctx context.Context) {
+	if written, ok := ctx.Value(writeTrackerKey).(*int32); ok {
+		atomic.StoreInt32(written, 1)
+	}
+}
+
+func (m *ConnManager) pinnedToPrimary(ctx context.Context) bool {
+	if pinned, _ := ctx.Value(pinPrimaryKey).(bool); pinned {
+		return true
+	}
+
+	if !m.config.PinReadsAfterWrite {
+		return false
+	}
+
+	written, ok := ctx.Value(writeTrackerKey).(*int32)
+	return ok && atomic.LoadInt32(written) == 1
+}
+
+// ConnManager opens and owns named database pools, and keeps track of their health:
+//
+//	manager, err := database.NewConnManager(database.ConnManagerConfig{
+//		Pools: map[string]database.PoolConfig{
+//			"payments": {Driver: "mysql", DSN: dsn, MaxOpenConns: 20},
+//		},
+//		PingInterval: 10 * time.Second,
+//	})
+//	db, err := manager.Get("payments")
+//
+// A database can have read replicas (see PoolConfig.Replicas); Reader and the read-only helpers
+// use them round-robin, skipping the unhealthy ones, and fall back to the primary:
+//
+//	ctx = database.TrackWrites(ctx)
+//	_, err = manager.ExecContext(ctx, "payments", "UPDATE payments SET status=? WHERE id=?", status, id)
+//	rows, err := manager.QueryContext(ctx, "payments", "SELECT * FROM payments WHERE id=?", id) // primary
+type ConnManager struct {
+	config ConnManagerConfig
+
+	mutex  sync.RWMutex
+	pools  map[string]*pool
+	closed bool
+	done   chan struct{}
+}
+
+// NewConnManager opens and pings all the configured pools; if any of them fails, the ones
+// already opened are closed and the error is returned (unreachable replicas don't fail, see Open)
+func NewConnManager(config ConnManagerConfig) (*ConnManager, error) {
+
+	if config.PingTimeout <= 0 {
+		config.PingTimeout = 2 * time.Second
+	}
+
+	m := &ConnManager{
+		config: config,
+		pools:  make(map[string]*pool),
+		done:   make(chan struct{}),
+	}
+
+	// open in order, so the result doesn't depend on the map order
+	names := make([]string, 0, len(config.Pools))
+	for name := range config.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := m.Open(name, config.Pools[name]); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+
+	if config.PingInterval > 0 {
+		go m.monitor()
+	}
+
+	return m, nil
+}
+
+// Open opens and pings a new pool, and its replicas; replicas that fail the ping are added as
+// unhealthy instead of failing the whole pool
+func (m *ConnManager) Open(name string, config PoolConfig) error {
+
+	db, err := openPool(config)
+	if err != nil {
+		return err
+	}
+
+	if err = pingPool(db, m.config.PingTimeout); err != nil {
+		db.Close()
+		return err
+	}
+
+	// replicas that can't be reached are added as unhealthy, so they're skipped until a ping
+	// (ie: the monitor) finds them healthy again
+	primary := &pool{db: db, health: poolHealth(db, nil)}
+	for _, replicaConfig := range config.Replicas {
+		if db, err = openPool(replicaConfig); err != nil {
+			primary.close()
+			return err
+		}
+		primary.replicas = append(primary.replicas, &pool{db: db, health: poolHealth(db, pingPool(db, m.config.PingTimeout))})
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		primary.close()
+		return ErrManagerClosed
+	}
+
+	if _, exists := m.pools[name]; exists {
+		primary.close()
+		return ErrPoolExists
+	}
+
+	for i := range primary.replicas {
+		if _, exists := m.pools[replicaName(name, i)]; exists {
+			primary.close()
+			return ErrPoolExists
+		}
+	}
+
+	m.pools[name] = primary
+	for i, replica := range primary.replicas {
+		m.pools[replicaName(name, i)] = replica
+	}
+
+	return nil
+}
+
+// Get returns the pool with the name
+func (m *ConnManager) Get(name string) (*sql.DB, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	p, found := m.pools[name]
+	if !found {
+		return nil, ErrPoolNotFound
+	}
+
+	return p.db, nil
+}
+
+// Writer returns the primary pool of the database, and records a write in the context (see TrackWrites)
+func (m *ConnManager) Writer(ctx context.Context, name string) (*sql.DB, error) {
+
+	db, err := m.Get(name)
+	if err == nil {
+		markWrite(ctx)
+	}
+	return db, err
+}
+
+// Reader returns the next healthy replica of the database, round-robin; it returns the primary
+// when there are no healthy replicas, or when the reads are pinned to it (see PinPrimary and TrackWrites)
+func (m *ConnManager) Reader(ctx context.Context, name string) (*sql.DB, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	p, found := m.pools[name]
+	if !found {
+		return nil, ErrPoolNotFound
+	}
+
+	if len(p.replicas) == 0 || m.pinnedToPrimary(ctx) {
+		return p.db, nil
+	}
+
+	start := atomic.AddUint32(&p.next, 1) - 1
+	for i := range p.replicas {
+		replica := p.replicas[(int(start)+i)%len(p.replicas)]
+		if replica.health.Healthy {
+			return replica.db, nil
+		}
+	}
+
+	return p.db, nil
+}
+
+// ExecContext executes a statement on the primary of the database
+func (m *ConnManager) ExecContext(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error) {
+
+	db, err := m.Writer(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a read-only query on a replica of the database (see Reader)
+func (m *ConnManager) QueryContext(ctx context.Context, name string, query string, args ...interface{}) (*sql.Rows, error) {
+
+	db, err := m.Reader(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a read-only query that returns a single row on a replica of the
+// database (see Reader); the query errors are deferred to the row's Scan
+func (m *ConnManager) QueryRowContext(ctx context.Context, name string, query string, args ...interface{}) (*sql.Row, error) {
+
+	db, err := m.Reader(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryRowContext(ctx, query, args...), nil
+}
+
+// Names returns the names of the pools (including the replicas), sorted
+func (m *ConnManager) Names() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	names := make([]string, 0, len(m.pools))
+	for name := range m.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ping pings a pool and updates its health (see Checker)
+func (m *ConnManager) Ping(ctx context.Context, name string) error {
+
+	m.mutex.RLock()
+	p, found := m.pools[name]
+	m.mutex.RUnlock()
+
+	if !found {
+		return ErrPoolNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.PingTimeout)
+	defer cancel()
+
+	err := p.db.PingContext(ctx)
+	m.setHealth(name, p, poolHealth(p.db, err))
+	return err
+}
+
+// Checker returns a health checker that pings the pool, to be registered in a healthcheck.Health:
+//
+//	health.Register("payments", manager.Checker("payments"))
+func (m *ConnManager) Checker(name string) healthcheck.CheckerFunc {
+	return func(ctx context.Context) error {
+		return m.Ping(ctx, name)
+	}
+}
+
+// PingAll pings all the pools, concurrently
+func (m *ConnManager) PingAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, name := range m.Names() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			m.Ping(ctx, name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+// Health returns the health of every pool after the last ping
+func (m *ConnManager) Health() map[string]PoolHealth {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	health := make(map[string]PoolHealth, len(m.pools))
+	for name, p := range m.pools {
+		health[name] = p.health
+	}
+	return health
+}
+
+// Healthy returns true if all the pools were healthy after the last ping
+func (m *ConnManager) Healthy() bool {
+	for _, health := range m.Health() {
+		if !health.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Close stops the health pings and closes all the pools
+func (m *ConnManager) Close() (err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	m.closed = true
+	close(m.done)
+
+	for name, p := range m.pools {
+		if closeErr := p.db.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(m.pools, name)
+	}
+
+	return
+}
+
+// pings all the pools every PingInterval, until the manager is closed
+func (m *ConnManager) monitor() {
+	ticker := time.NewTicker(m.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.PingAll(context.Background())
+		}
+	}
+}
+
+func (m *ConnManager) setHealth(name string, p *pool, health PoolHealth) {
+	m.mutex.Lock()
+	changed := p.health.Healthy != health.Healthy
+	p.health = health
+	m.mutex.Unlock()
+
+	if changed && m.config.OnHealthChange != nil {
+		m.config.OnHealthChange(name, health)
+	}
+}
+
+// closes the pool and its replicas
+func (p *pool) close() {
+	p.db.Close()
+	for _, replica := range p.replicas {
+		replica.db.Close()
+	}
+}
+
+func replicaName(name string, i int) string {
+	return fmt.Sprintf("%s/replica-%d", name, i+1)
+}
+
+func openPool(config PoolConfig) (*sql.DB, error) {
+
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	// zero values keep the database/sql defaults
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+
+	return db, nil
+}
+
+func pingPool(db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
+
+func poolHealth(db *sql.DB, err error) PoolHealth {
+	stats := db.Stats()
+	health := PoolHealth{
+		Healthy:         err == nil,
+		CheckedAt:       time.Now(),
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		Idle:            stats.Idle,
+		WaitCount:       stats.WaitCount,
+	}
+
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	return health
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// driver whose connections fail while the server named by the DSN is down
+type flakyDriver struct{}
+
+type flakyConn struct {
+	server string
+}
+
+var (
+	downServers   sync.Map
+	errServerDown = errors.New("connection refused")
+)
+
+func init() {
+	sql.Register("flaky", flakyDriver{})
+}
+
+func (flakyDriver) Open(server string) (driver.Conn, error) {
+	if _, down := downServers.Load(server); down {
+		return nil, errServerDown
+	}
+	return &flakyConn{server}, nil
+}
+
+func (c *flakyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *flakyConn) Close() error              { return nil }
+func (c *flakyConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c *flakyConn) Ping(ctx context.Context) error {
+	if _, down := downServers.Load(c.server); down {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// test cases for ConnManager
+func TestConnManager(t *testing.T) {
+
+	var changes int32
+	manager, err := NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"payments": {Driver: "sqlite3", DSN: ":memory:", MaxOpenConns: 2},
+			"ledger":   {Driver: "flaky", DSN: "ledger-1"},
+		},
+		PingInterval:   5 * time.Millisecond,
+		OnHealthChange: func(name string, health PoolHealth) { atomic.AddInt32(&changes, 1) },
+	})
+	if err != nil {
+		t.Fatalf("NewConnManager() returned an error: %s", err.Error())
+	}
+	defer manager.Close()
+
+	assert.Equal(t, []string{"ledger", "payments"}, manager.Names())
+
+	db, err := manager.Get("payments")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, db.Stats().MaxOpenConnections)
+
+	_, err = manager.Get("users")
+	assert.Equal(t, ErrPoolNotFound, err)
+
+	assert.Equal(t, ErrPoolExists, manager.Open("payments", PoolConfig{Driver: "sqlite3", DSN: ":memory:"}))
+	assert.True(t, manager.Healthy())
+
+	// the periodic pings find the server down, and then up again
+	downServers.Store("ledger-1", true)
+	waitFor(t, func() bool { return !manager.Health()["ledger"].Healthy })
+	assert.False(t, manager.Healthy())
+	assert.NotEmpty(t, manager.Health()["ledger"].Error)
+
+	assert.NotNil(t, manager.Checker("ledger").Check(context.Background()))
+
+	downServers.Delete("ledger-1")
+	waitFor(t, func() bool { return manager.Healthy() })
+	assert.Nil(t, manager.Checker("ledger").Check(context.Background()))
+	assert.Equal(t, ErrPoolNotFound, manager.Checker("users").Check(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&changes))
+
+	assert.Nil(t, manager.Close())
+	_, err = manager.Get("payments")
+	assert.Equal(t, ErrManagerClosed, err)
+}
+
+// test cases for ConnManager with pools that can't be opened
+func TestConnManagerOpenError(t *testing.T) {
+
+	downServers.Store("ledger-2", true)
+	defer downServers.Delete("ledger-2")
+
+	_, err := NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"ledger": {Driver: "flaky", DSN: "ledger-2"},
+		},
+	})
+	assert.Equal(t, errServerDown, err)
+
+	_, err = NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"ledger": {Driver: "oracle"},
+		},
+	})
+	if err == nil {
+		t.Errorf("NewConnManager() should have returned an error")
+	}
+}
+
+// test cases for ConnManager with read replicas
+func TestConnManagerReplicas(t *testing.T) {
+
+	manager, err := NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"payments": {
+				Driver: "flaky",
+				DSN:    "payments-primary",
+				Replicas: []PoolConfig{
+					{Driver: "flaky", DSN: "payments-replica-1"},
+					{Driver: "flaky", DSN: "payments-replica-2"},
+				},
+			},
+			"ledger": {Driver: "flaky", DSN: "ledger-3"},
+		},
+		PinReadsAfterWrite: true,
+	})
+	if err != nil {
+		t.Fatalf("NewConnManager() returned an error: %s", err.Error())
+	}
+	defer manager.Close()
+
+	assert.Equal(t, []string{"ledger", "payments", "payments/replica-1", "payments/replica-2"}, manager.Names())
+
+	primary, _ := manager.Get("payments")
+	replica1, _ := manager.Get("payments/replica-1")
+	replica2, _ := manager.Get("payments/replica-2")
+	ctx := context.Background()
+
+	// round-robin
+	for _, expected := range []*sql.DB{replica1, replica2, replica1} {
+		db, err := manager.Reader(ctx, "payments")
+		assert.Nil(t, err)
+		assert.True(t, expected == db)
+	}
+
+	// databases without replicas read from the primary
+	ledger, _ := manager.Get("ledger")
+	db, _ := manager.Reader(ctx, "ledger")
+	assert.True(t, ledger == db)
+
+	_, err = manager.Reader(ctx, "users")
+	assert.Equal(t, ErrPoolNotFound, err)
+
+	// unhealthy replicas are skipped
+	downServers.Store("payments-replica-1", true)
+	defer downServers.Delete("payments-replica-1")
+	assert.NotNil(t, manager.Ping(ctx, "payments/replica-1"))
+
+	for i := 0; i < 3; i++ {
+		db, _ = manager.Reader(ctx, "payments")
+		assert.True(t, replica2 == db)
+	}
+
+	downServers.Store("payments-replica-2", true)
+	defer downServers.Delete("payments-replica-2")
+	assert.NotNil(t, manager.Ping(ctx, "payments/replica-2"))
+
+	db, _ = manager.Reader(ctx, "payments")
+	assert.True(t, primary == db)
+
+	downServers.Delete("payments-replica-2")
+	assert.Nil(t, manager.Ping(ctx, "payments/replica-2"))
+
+	// reads after a write in the same context go to the primary
+	tracked := TrackWrites(ctx)
+	db, _ = manager.Reader(tracked, "payments")
+	assert.True(t, replica2 == db)
+
+	db, err = manager.Writer(tracked, "payments")
+	assert.Nil(t, err)
+	assert.True(t, primary == db)
+
+	db, _ = manager.Reader(tracked, "payments")
+	assert.True(t, primary == db)
+
+	db, _ = manager.Reader(ctx, "payments")
+	assert.True(t, replica2 == db)
+
+	db, _ = manager.Reader(PinPrimary(ctx), "payments")
+	assert.True(t, primary == db)
+}
+
+// test cases for ConnManager read and write helpers
+func TestConnManagerQueries(t *testing.T) {
+
+	manager, err := NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"payments": {
+				Driver:       "sqlite3",
+				DSN:          "file:primary?mode=memory&cache=shared",
+				MaxOpenConns: 1,
+				Replicas:     []PoolConfig{{Driver: "sqlite3", DSN: "file:replica?mode=memory&cache=shared", MaxOpenConns: 1}},
+			},
+		},
+		PinReadsAfterWrite: true,
+	})
+	if err != nil {
+		t.Fatalf("NewConnManager() returned an error: %s", err.Error())
+	}
+	defer manager.Close()
+
+	ctx := TrackWrites(context.Background())
+	_, err = manager.ExecContext(ctx, "payments", "CREATE TABLE payments (id INTEGER PRIMARY KEY, amount REAL)")
+	assert.Nil(t, err)
+	_, err = manager.ExecContext(ctx, "payments", "INSERT INTO payments (amount) VALUES (?)", 10.5)
+	assert.Nil(t, err)
+
+	// the write is visible in the same context, but not yet in the replica
+	var amount float64
+	row, err := manager.QueryRowContext(ctx, "payments", "SELECT amount FROM payments WHERE id=?", 1)
+	assert.Nil(t, err)
+	assert.Nil(t, row.Scan(&amount))
+	assert.Equal(t, 10.5, amount)
+
+	rows, err := manager.QueryContext(ctx, "payments", "SELECT amount FROM payments")
+	assert.Nil(t, err)
+	rows.Close()
+
+	_, err = manager.QueryContext(context.Background(), "payments", "SELECT amount FROM payments")
+	if err == nil {
+		t.Errorf("QueryContext() should have read from the replica")
+	}
+
+	_, err = manager.ExecContext(ctx, "users", "DELETE FROM users")
+	assert.Equal(t, ErrPoolNotFound, err)
+}
+
+// test cases for ConnManager with replicas that are down at start
+func TestConnManagerReplicaDown(t *testing.T) {
+
+	downServers.Store("ledger-replica", true)
+	defer downServers.Delete("ledger-replica")
+
+	manager, err := NewConnManager(ConnManagerConfig{
+		Pools: map[string]PoolConfig{
+			"ledger": {
+				Driver:   "flaky",
+				DSN:      "ledger-4",
+				Replicas: []PoolConfig{{Driver: "flaky", DSN: "ledger-replica"}},
+			},
+		},
+		PingInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewConnManager() returned an error: %s", err.Error())
+	}
+	defer manager.Close()
+
+	// the replica is added as unhealthy, and the reads go to the primary
+	assert.Equal(t, []string{"ledger", "ledger/replica-1"}, manager.Names())
+	assert.False(t, manager.Health()["ledger/replica-1"].Healthy)
+	assert.Equal(t, errServerDown.Error(), manager.Health()["ledger/replica-1"].Error)
+
+	ctx := context.Background()
+	primary, _ := manager.Get("ledger")
+	replica, _ := manager.Get("ledger/replica-1")
+	db, _ := manager.Reader(ctx, "ledger")
+	assert.True(t, primary == db)
+
+	// until the monitor finds it healthy
+	downServers.Delete("ledger-replica")
+	waitFor(t, func() bool { return manager.Health()["ledger/replica-1"].Healthy })
+	db, _ = manager.Reader(ctx, "ledger")
+	assert.True(t, replica == db)
+
+	// replicas with invalid configs still fail
+	err = manager.Open("payments", PoolConfig{Driver: "flaky", DSN: "payments-3", Replicas: []PoolConfig{{Driver: "oracle"}}})
+	if err == nil {
+		t.Errorf("Open() should have returned an error")
+	}
+
+	assert.Nil(t, manager.Open("users/replica-1", PoolConfig{Driver: "flaky", DSN: "users-1"}))
+	err = manager.Open("users", PoolConfig{
+		Driver:   "flaky",
+		DSN:      "users-2",
+		Replicas: []PoolConfig{{Driver: "flaky", DSN: "users-3"}},
+	})
+	assert.Equal(t, ErrPoolExists, err)
+	assert.Equal(t, []string{"ledger", "ledger/replica-1", "users/replica-1"}, manager.Names())
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
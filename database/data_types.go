@@ -11,10 +11,24 @@ const (
 	DbTypeNumeric DBType = "NUMERIC"
 	DbTypeDate    DBType = "DATE"
 	DbTypeBool    DBType = "BOOLEAN"
+	DbTypeJSON    DBType = "JSON"
 	// others...
 )
 
+// lint mode of the literal builder (see SetStrictLiterals)
+var strictLiterals int32
+
+// format used for time literals, accepted by MySQL, Postgres and SQLite; times are converted to
+// UTC first, like the drivers do with the parameters by default (ie: mysql loc=UTC)
+const timeLiteralFormat = "2006-01-02 15:04:05.999999"
+
 // Database util errors
 var (
-	ErrInvalidFieldList = errors.New("invalid field list")
+	ErrInvalidFieldList   = errors.New("invalid field list")
+	ErrNoTable            = errors.New("table name is required")
+	ErrNoConditions       = errors.New("at least one condition is required")
+	ErrMatchesAll         = errors.New("conditions match all the rows")
+	ErrNullValue          = errors.New("null value not allowed")
+	ErrUnsafeLiteral      = errors.New("value is unsafe to use as a literal")
+	ErrUnsupportedDialect = errors.New("query not supported by the dialect")
 )
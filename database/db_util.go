@@ -2,16 +2,25 @@ package database
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/astropay/go-tools/common"
 )
 
 // BuildUpdateSetQuery returns a string that can be used to build a set query, with
-// the values put as part of the string
+// the values put as part of the string. Nil pointers are set to NULL, unless the field
+// is tagged with `db_nullable:"false"`. String values are escaped for the default dialect;
+// see SetStrictLiterals to find the values that need it. The MySQL escaping can't depend on
+// the server mode: with NO_BACKSLASH_ESCAPES, backslashes and control characters (ie: new
+// lines) are stored escaped (`\\` or `\n`) instead of their value.
+//
+// Deprecated: values in the query string are error prone; use BuildParametrizedUpdateSet.
 func BuildUpdateSetQuery(obj interface{}, fields []string) (string, error) {
 
 	checkType := reflect.TypeOf(obj)
@@ -38,30 +47,32 @@ func BuildUpdateSetQuery(obj interface{}, fields []string) (string, error) {
 		buf := new(bytes.Buffer)
 		buf.WriteString("SET ")
 
+		meta := getTypeMeta(objType)
+
 		for i := 0; i < len(fields); i++ {
 			var (
-				field  reflect.StructField
+				field  *fieldMeta
 				exists bool
 			)
 
-			if field, exists = objType.FieldByName(fields[i]); exists {
+			if field, exists = meta.fieldByName(fields[i]); exists {
 
-				fieldInstance := objVal.FieldByName(fields[i])
-				colName := resolveColumnName(field)
-				colType := resolveColumnType(field)
+				fieldInstance := objVal.FieldByIndex(field.field.Index)
+				colName := field.column
+				colType := field.dbType
 
-				// get field value
-				fieldKind := fieldInstance.Kind()
-				var fieldValue interface{}
-
-				if fieldKind == reflect.Ptr {
-					fieldValue = fieldInstance.Elem().Interface()
-				} else {
-					fieldValue = fieldInstance.Interface()
+				// get field value (nil pointers are NULL)
+				fieldValue, err := getFieldValue(field, fieldInstance)
+				if err != nil {
+					return "", err
 				}
 
 				// create field=value string
-				buf.WriteString(fmt.Sprintf(buildFieldSet(colType), colName, fieldValue))
+				literal, err := formatValue(fieldValue, colType)
+				if err != nil {
+					return "", fmt.Errorf("invalid value for column '%s': %w", colName, err)
+				}
+				buf.WriteString(DefaultDialect().Quote(colName) + "=" + literal)
 			} else {
 				return "", fmt.Errorf("invalid field '%s'", fields[i])
 			}
@@ -78,6 +89,21 @@ func BuildUpdateSetQuery(obj interface{}, fields []string) (string, error) {
 	return "", ErrInvalidFieldList
 }
 
+// SetStrictLiterals enables a lint mode for BuildUpdateSetQuery, where the values that would be
+// unsafe to interpolate without escaping (ie: containing quotes) return ErrUnsafeLiteral instead
+func SetStrictLiterals(strict bool) {
+	var value int32
+	if strict {
+		value = 1
+	}
+	atomic.StoreInt32(&strictLiterals, value)
+}
+
+// StrictLiterals returns true if the lint mode is enabled
+func StrictLiterals() bool {
+	return atomic.LoadInt32(&strictLiterals) == 1
+}
+
 // BuildParametrizedUpdateSetQuery returns a string that can be used to build a set query,
 // using parameters instead of values (parameter used is '?')
 func BuildParametrizedUpdateSetQuery(obj interface{}, fields []string) (string, error) {
@@ -102,14 +128,16 @@ func BuildParametrizedUpdateSetQuery(obj interface{}, fields []string) (string,
 		buf := new(bytes.Buffer)
 		buf.WriteString("SET ")
 
+		meta := getTypeMeta(objType)
+
 		for i := 0; i < len(fields); i++ {
 			var (
-				field  reflect.StructField
+				field  *fieldMeta
 				exists bool
 			)
 
-			if field, exists = objType.FieldByName(fields[i]); exists {
-				colName := resolveColumnName(field)
+			if field, exists = meta.fieldByName(fields[i]); exists {
+				colName := field.column
 				buf.WriteString(fmt.Sprintf("%s=?", colName))
 			} else {
 				return "", fmt.Errorf("invalid field '%s'", fields[i])
@@ -127,6 +155,19 @@ func BuildParametrizedUpdateSetQuery(obj interface{}, fields []string) (string,
 	return "", ErrInvalidFieldList
 }
 
+// BuildParametrizedUpdateSet returns a set query using parameters instead of values (parameter
+// used is '?') and the values of the fields, in the same order. Extra arguments (ie: for the where
+// condition) are added in the end.
+func BuildParametrizedUpdateSet(obj interface{}, fields []string, args ...interface{}) (set string, params []interface{}, err error) {
+
+	if set, err = BuildParametrizedUpdateSetQuery(obj, fields); err != nil {
+		return
+	}
+
+	params, err = GetParameterValues(obj, fields, args...)
+	return
+}
+
 // BuildNamedParametersUpdateSetQuery returns a string that can be used to build a set query,
 // using named parameters (:param_name)
 func BuildNamedParametersUpdateSetQuery(obj interface{}, fields []string) (string, error) {
@@ -151,14 +192,16 @@ func BuildNamedParametersUpdateSetQuery(obj interface{}, fields []string) (strin
 		buf := new(bytes.Buffer)
 		buf.WriteString("SET ")
 
+		meta := getTypeMeta(objType)
+
 		for i := 0; i < len(fields); i++ {
 			var (
-				field  reflect.StructField
+				field  *fieldMeta
 				exists bool
 			)
 
-			if field, exists = objType.FieldByName(fields[i]); exists {
-				colName := resolveColumnName(field)
+			if field, exists = meta.fieldByName(fields[i]); exists {
+				colName := field.column
 				buf.WriteString(fmt.Sprintf("%s=:%s", colName, colName))
 			} else {
 				return "", fmt.Errorf("invalid field '%s'", fields[i])
@@ -206,9 +249,8 @@ func BuildNamedParametersUpdateSetQueryV2(obj interface{}, fields []string) (str
 		fieldCount := 0
 
 		// loop through all fields
-		for i := 0; i < objType.NumField(); i++ {
-			fieldInstance := objType.Field(i)
-			colName := resolveColumnName(fieldInstance)
+		for _, field := range getTypeMeta(objType).fields {
+			colName := field.column
 
 			if _, found := common.FindInStringArray(colName, fields); found {
 				buf.WriteString(fmt.Sprintf("%s=:%s", colName, colName))
@@ -231,8 +273,9 @@ func BuildNamedParametersUpdateSetQueryV2(obj interface{}, fields []string) (str
 // Those fields indicated in 'skipFields' will not be included in the list; this is useful
 // when dealing with auto incremental fields.
 //
-// Flag 'quoted' makes all the fields to be wrapped as `field_name`; 'asNamedParameter' returns
-// all fields as :field_name, useful for named queries. Flags are exclusive, use one or the other.
+// Flag 'quoted' makes all the fields to be quoted for the default dialect (`field_name` for MySQL);
+// 'asNamedParameter' returns all fields as :field_name, useful for named queries. Flags are exclusive,
+// use one or the other.
 //
 // Note: those fields without the 'db' attribute or marked with a dash (`db:"-"`) are ignored.
 func GetAllFields(obj interface{}, skipFields []string, quoted bool, asNamedParameter bool) (fieldList string, err error) {
@@ -256,16 +299,20 @@ func GetAllFields(obj interface{}, skipFields []string, quoted bool, asNamedPara
 	buf := new(bytes.Buffer)
 
 	// loop through all fields
-	for i := 0; i < objType.NumField(); i++ {
-		fieldInstance := objType.Field(i)
+	for _, field := range getTypeMeta(objType).fields {
 
-		if _, found := common.FindInStringArray(fieldInstance.Name, skipFields); !found {
+		// blank fields are only used for tags (ie: db_table)
+		if field.field.Name == "_" {
+			continue
+		}
+
+		if _, found := common.FindInStringArray(field.field.Name, skipFields); !found {
 
-			colName := resolveColumnName(fieldInstance)
+			colName := field.column
 
 			if colName != "-" && colName != "" {
 				if quoted {
-					buf.WriteString("`" + colName + "`" + ",")
+					buf.WriteString(DefaultDialect().Quote(colName) + ",")
 				} else if asNamedParameter {
 					buf.WriteString(":" + colName + ",")
 				} else {
@@ -313,19 +360,15 @@ func GetParameterValues(obj interface{}, fields []string, args ...interface{}) (
 		}
 
 		params := make([]interface{}, len(fields)+otherFields)
+		meta := getTypeMeta(objType)
 
 		for i := 0; i < len(fields); i++ {
-			if _, exists := objType.FieldByName(fields[i]); exists {
+			if field, exists := meta.fieldByName(fields[i]); exists {
 
-				// get field value
-				var fieldValue interface{}
-				fieldInstance := objVal.FieldByName(fields[i])
-				fieldKind := fieldInstance.Kind()
-
-				if fieldKind == reflect.Ptr {
-					fieldValue = fieldInstance.Elem().Interface()
-				} else {
-					fieldValue = fieldInstance.Interface()
+				// get field value (nil pointers are nil)
+				fieldValue, err := getFieldValue(field, objVal.FieldByIndex(field.field.Index))
+				if err != nil {
+					return nil, err
 				}
 
 				// add field value to array
@@ -428,19 +471,93 @@ func GetChangedFields(original interface{}, new interface{}, skipFields []string
 	return
 }
 
-func buildFieldSet(dbType DBType) string {
-	// format strings used to build sentences
-	quotedFormat := "`%s`='%v'"
-	unquotedFormat := "`%s`=%v"
+// formats a value to be used as a literal; time.Time, []byte and driver.Valuer implementations (ie:
+// sql.NullString or decimal types) are formatted from their driver value, and the db type is used for the rest
+func formatValue(value interface{}, dbType DBType) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case time.Time, []byte:
+		return formatDriverValue(v)
+	case driver.Valuer:
+		driverValue, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		return formatDriverValue(driverValue)
+	}
 
 	switch dbType {
-	case DbTypeVarchar, DbTypeDate:
-		return quotedFormat
 	case DbTypeBool, DbTypeNumeric:
-		return unquotedFormat
+		return formatUnquoted(value)
+	case DbTypeVarchar, DbTypeDate, DbTypeJSON:
+		return quoteLiteral(fmt.Sprintf("%v", value))
+	default:
+		return formatUnquoted(value)
+	}
+}
+
+// formats one of the driver.Value types
+func formatDriverValue(value driver.Value) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case time.Time:
+		return quoteLiteral(v.UTC().Format(timeLiteralFormat))
+	case []byte:
+		return quoteLiteral(string(v))
+	case string:
+		return quoteLiteral(v)
+	default:
+		return formatUnquoted(v)
+	}
+}
+
+// only numbers and booleans are written without quotes; anything else (ie: a string field
+// tagged as numeric) is quoted, so it can't be injected as is
+func formatUnquoted(value interface{}) (string, error) {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", value), nil
 	default:
-		return unquotedFormat
+		return quoteLiteral(fmt.Sprintf("%v", value))
+	}
+}
+
+// quotes and escapes a string literal for the default dialect; in strict mode, values that
+// need escaping return ErrUnsafeLiteral
+func quoteLiteral(value string) (string, error) {
+	literal := DefaultDialect().QuoteString(value)
+
+	if StrictLiterals() && literal != "'"+value+"'" {
+		return "", ErrUnsafeLiteral
+	}
+
+	return literal, nil
+}
+
+// returns the value of a field to be used in a query; nil pointers are NULL, unless the
+// field is tagged with `db_nullable:"false"`, and json fields are marshaled
+func getFieldValue(field *fieldMeta, value reflect.Value) (fieldValue interface{}, err error) {
+
+	if field.dbType == DbTypeJSON {
+		fieldValue, err = marshalJSONValue(value)
+	} else if value.Kind() == reflect.Ptr {
+		if !value.IsNil() {
+			fieldValue = value.Elem().Interface()
+		}
+	} else {
+		fieldValue = value.Interface()
+	}
+
+	if err == nil && fieldValue == nil && !field.nullable {
+		err = fmt.Errorf("%w for column '%s'", ErrNullValue, field.column)
 	}
+
+	return
 }
 
 // resolves the column name associated to a struct's field;
@@ -1,7 +1,12 @@
 package database
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -335,3 +340,184 @@ func TestGetChangedFieldsWithPointers(t *testing.T) {
 	}
 
 }
+
+type Payment struct {
+	ID        int             `db:"id_payment"`
+	Amount    decimalAmount   `db:"amount"`
+	Reference sql.NullString  `db:"reference"`
+	PaidAt    sql.NullTime    `db:"paid_at"`
+	CreatedAt time.Time       `db:"created_at"`
+	Retries   sql.NullInt64   `db:"retries"`
+	Approved  sql.NullBool    `db:"approved"`
+	Rate      sql.NullFloat64 `db:"rate"`
+}
+
+// decimal type implementing driver.Valuer
+type decimalAmount struct {
+	units int64
+	scale int
+}
+
+func (d decimalAmount) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%0*d", d.units/100, d.scale, d.units%100), nil
+}
+
+// test cases for BuildUpdateSetQuery() with time.Time, sql.Null* and driver.Valuer fields
+func TestBuildUpdateSetQueryDriverValues(t *testing.T) {
+
+	witnessStr := "SET `amount`='1250.75',`reference`=NULL,`paid_at`='2020-03-01 10:30:00',`created_at`='2020-03-01 10:29:59.5'," +
+		"`retries`=3,`approved`=true,`rate`=NULL"
+
+	createdAt := time.Date(2020, 3, 1, 10, 29, 59, 500000000, time.UTC)
+	payment := Payment{
+		ID:        1,
+		Amount:    decimalAmount{units: 125075, scale: 2},
+		PaidAt:    sql.NullTime{Time: createdAt.Add(500 * time.Millisecond), Valid: true},
+		CreatedAt: createdAt,
+		Retries:   sql.NullInt64{Int64: 3, Valid: true},
+		Approved:  sql.NullBool{Bool: true, Valid: true},
+	}
+
+	builtSetStr, err := BuildUpdateSetQuery(payment, []string{"Amount", "Reference", "PaidAt", "CreatedAt", "Retries", "Approved", "Rate"})
+	if err != nil {
+		t.Errorf("BuildUpdateSetQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, witnessStr, builtSetStr)
+	}
+
+	// parametrized queries pass the values through, so the driver converts them
+	params, err := GetParameterValues(payment, []string{"PaidAt", "CreatedAt"})
+	if err != nil {
+		t.Errorf("GetParameterValues() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, []interface{}{payment.PaidAt, createdAt}, params)
+	}
+}
+
+type Document struct {
+	ID        int       `db:"id_document"`
+	Content   []byte    `db:"content"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// test cases for BuildUpdateSetQuery() with []byte fields and times in other locations
+func TestBuildUpdateSetQueryBytesAndLocation(t *testing.T) {
+
+	// the literal is the same instant the driver would store for the parameter (UTC)
+	montevideo := time.FixedZone("UYT", -3*60*60)
+	document := Document{ID: 1, Content: []byte("hi"), UpdatedAt: time.Date(2020, 3, 1, 7, 30, 0, 0, montevideo)}
+
+	builtSetStr, err := BuildUpdateSetQuery(document, []string{"Content", "UpdatedAt"})
+	if err != nil {
+		t.Errorf("BuildUpdateSetQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SET `content`='hi',`updated_at`='2020-03-01 10:30:00'", builtSetStr)
+	}
+
+	document.Content = []byte("it's")
+	builtSetStr, _ = BuildUpdateSetQuery(document, []string{"Content"})
+	assert.Equal(t, "SET `content`='it''s'", builtSetStr)
+}
+
+type Contact struct {
+	ID    int     `db:"id_contact"`
+	Phone *string `db:"phone"`
+	Email *string `db:"email" db_nullable:"false"`
+}
+
+// test cases for nil pointer fields in the builders
+func TestBuildersNilPointers(t *testing.T) {
+
+	contact := Contact{ID: 1}
+
+	builtSetStr, err := BuildUpdateSetQuery(contact, []string{"Phone"})
+	if err != nil {
+		t.Errorf("BuildUpdateSetQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SET `phone`=NULL", builtSetStr)
+	}
+
+	params, err := GetParameterValues(contact, []string{"Phone"}, 1)
+	if err != nil {
+		t.Errorf("GetParameterValues() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, []interface{}{nil, 1}, params)
+	}
+
+	// not nullable columns
+	_, err = BuildUpdateSetQuery(contact, []string{"Phone", "Email"})
+	assert.True(t, errors.Is(err, ErrNullValue))
+	assert.Equal(t, "null value not allowed for column 'email'", err.Error())
+
+	_, err = GetParameterValues(&contact, []string{"Email"})
+	assert.True(t, errors.Is(err, ErrNullValue))
+
+	_, _, err = BuildInsertQuery("contacts", contact)
+	assert.True(t, errors.Is(err, ErrNullValue))
+
+	email := "john@mail.com"
+	contact.Email = &email
+	_, args, err := BuildInsertQuery("contacts", contact)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, nil, "john@mail.com"}, args)
+}
+
+// test cases for the escaping of BuildUpdateSetQuery()
+func TestBuildUpdateSetQueryEscaping(t *testing.T) {
+
+	name := `O'Brien \ "Jr"`
+	address := "'; DROP TABLE users; --"
+	user := User{Name: &name, Address: &address}
+
+	builtSetStr, err := BuildUpdateSetQuery(user, []string{"Name", "Address"})
+	if err != nil {
+		t.Errorf("BuildUpdateSetQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SET `name`='O''Brien \\\\ \"Jr\"',`address`='''; DROP TABLE users; --'", builtSetStr)
+	}
+
+	SetDefaultDialect(Postgres)
+	builtSetStr, _ = BuildUpdateSetQuery(user, []string{"Name"})
+	SetDefaultDialect(MySQL)
+	assert.Equal(t, `SET "name"='O''Brien \ "Jr"'`, builtSetStr)
+
+	// non numeric values tagged as numeric are quoted
+	type Account struct {
+		Balance string `db:"balance" db_type:"numeric"`
+	}
+
+	builtSetStr, _ = BuildUpdateSetQuery(Account{Balance: "0 WHERE 1=1"}, []string{"Balance"})
+	assert.Equal(t, "SET `balance`='0 WHERE 1=1'", builtSetStr)
+
+	// lint mode
+	SetStrictLiterals(true)
+	defer SetStrictLiterals(false)
+
+	_, err = BuildUpdateSetQuery(user, []string{"Address"})
+	assert.True(t, errors.Is(err, ErrUnsafeLiteral))
+	assert.Equal(t, "invalid value for column 'address': value is unsafe to use as a literal", err.Error())
+
+	city := "Montevideo"
+	user.City = &city
+	builtSetStr, err = BuildUpdateSetQuery(user, []string{"City"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SET `city`='Montevideo'", builtSetStr)
+}
+
+// test cases for BuildParametrizedUpdateSet()
+func TestBuildParametrizedUpdateSet(t *testing.T) {
+
+	name := "O'Brien"
+	user := User{ID: 145, Name: &name, Active: true}
+
+	set, params, err := BuildParametrizedUpdateSet(user, []string{"Name", "Active"}, 145)
+	if err != nil {
+		t.Errorf("BuildParametrizedUpdateSet() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SET name=?,active=?", set)
+		assert.Equal(t, []interface{}{"O'Brien", true, 145}, params)
+	}
+
+	_, _, err = BuildParametrizedUpdateSet(user, nil)
+	assert.Equal(t, ErrInvalidFieldList, err)
+}
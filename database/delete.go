@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// BuildDeleteQuery returns a parametrized DELETE statement and its arguments.
+//
+// Conditions can be a struct (or pointer to struct), where all the non-zero fields are
+// used as equality conditions (column names resolved from the 'db' tag), a map where
+// the keys are the column names (nil values match NULL columns), or a Condition. At least one condition is required, so
+// a whole table can't be deleted by mistake; conditions that match all the rows (ie: NotIn with an empty list)
+// return ErrMatchesAll. An empty table is resolved with TableName(conditions).
+func BuildDeleteQuery(table string, conditions interface{}) (query string, args []interface{}, err error) {
+
+	if table == "" {
+		if table, err = TableName(conditions); err != nil {
+			return
+		}
+	}
+
+	cond, ok := conditions.(Condition)
+	if !ok {
+		if cond, err = equalityCondition(conditions); err != nil {
+			return
+		}
+	}
+
+	var where string
+	if where, args = BuildWhereClause(cond); where == "" {
+		err = ErrNoConditions
+		return
+	}
+
+	if matchesAll(cond) {
+		err = ErrMatchesAll
+		return
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s %s", table, where)
+	return
+}
+
+// returns the equality conditions of a struct (non-zero fields) or a map, joined with AND; nil
+// map values are IS NULL conditions
+func equalityCondition(conditions interface{}) (cond Condition, err error) {
+
+	var columns []string
+	var values []interface{}
+	if columns, values, err = equalityConditions(conditions); err != nil {
+		return
+	}
+
+	eqs := make([]Condition, len(columns))
+	for i, col := range columns {
+		// col=NULL never matches
+		if isNilValue(values[i]) {
+			eqs[i] = IsNull(col)
+		} else {
+			eqs[i] = Eq(col, values[i])
+		}
+	}
+
+	cond = And(eqs...)
+	return
+}
+
+// returns the columns and values of the conditions, from a struct (non-zero fields) or a map
+func equalityConditions(conditions interface{}) (columns []string, values []interface{}, err error) {
+
+	if conditions == nil {
+		return
+	}
+
+	condVal := reflect.ValueOf(conditions)
+	if condVal.Kind() == reflect.Ptr {
+		condVal = condVal.Elem()
+	}
+
+	switch condVal.Kind() {
+
+	case reflect.Map:
+		if condVal.Type().Key().Kind() != reflect.String {
+			err = fmt.Errorf("invalid conditions map key type '%s'", condVal.Type().Key().Kind().String())
+			return
+		}
+
+		// sort columns so the query is always the same
+		for _, key := range condVal.MapKeys() {
+			columns = append(columns, key.String())
+		}
+		sort.Strings(columns)
+
+		for _, col := range columns {
+			values = append(values, condVal.MapIndex(reflect.ValueOf(col).Convert(condVal.Type().Key())).Interface())
+		}
+
+	case reflect.Struct:
+		for _, field := range getTypeMeta(condVal.Type()).columns {
+			fieldVal := condVal.FieldByIndex(field.field.Index)
+			if fieldVal.IsZero() {
+				continue
+			}
+
+			if fieldVal.Kind() == reflect.Ptr {
+				fieldVal = fieldVal.Elem()
+			}
+
+			columns = append(columns, field.column)
+			values = append(values, fieldVal.Interface())
+		}
+
+	default:
+		err = fmt.Errorf("invalid conditions type '%s'", condVal.Kind().String())
+	}
+
+	return
+}
+
+// returns true for nil and nil pointers
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	val := reflect.ValueOf(value)
+	return val.Kind() == reflect.Ptr && val.IsNil()
+}
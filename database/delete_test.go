@@ -0,0 +1,86 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for BuildDeleteQuery()
+func TestBuildDeleteQuery(t *testing.T) {
+
+	country := "UY"
+
+	// struct conditions: only non-zero fields
+	query, args, err := BuildDeleteQuery("users", &User{ID: 145, Country: &country})
+	if err != nil {
+		t.Errorf("BuildDeleteQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "DELETE FROM users WHERE id_user=? AND country=?", query)
+		assert.Equal(t, []interface{}{145, "UY"}, args)
+	}
+
+	// map conditions, sorted by column
+	query, args, err = BuildDeleteQuery("users", map[string]interface{}{"status": "inactive", "country": "UY"})
+	if err != nil {
+		t.Errorf("BuildDeleteQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "DELETE FROM users WHERE country=? AND status=?", query)
+		assert.Equal(t, []interface{}{"UY", "inactive"}, args)
+	}
+
+	// nil map values match NULL columns
+	var deletedAt *time.Time
+	query, args, err = BuildDeleteQuery("users", map[string]interface{}{"deleted_at": nil, "status": "inactive", "updated_at": deletedAt})
+	if err != nil {
+		t.Errorf("BuildDeleteQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "DELETE FROM users WHERE deleted_at IS NULL AND status=? AND updated_at IS NULL", query)
+		assert.Equal(t, []interface{}{"inactive"}, args)
+	}
+
+	// composed conditions
+	query, args, err = BuildDeleteQuery("sessions", Or(Lt("expires_at", "2020-01-01"), IsNull("id_user")))
+	if err != nil {
+		t.Errorf("BuildDeleteQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "DELETE FROM sessions WHERE expires_at<? OR id_user IS NULL", query)
+		assert.Equal(t, []interface{}{"2020-01-01"}, args)
+	}
+
+	// no conditions
+	_, _, err = BuildDeleteQuery("users", User{})
+	assert.Equal(t, ErrNoConditions, err)
+
+	_, _, err = BuildDeleteQuery("users", nil)
+	assert.Equal(t, ErrNoConditions, err)
+
+	_, _, err = BuildDeleteQuery("users", And())
+	assert.Equal(t, ErrNoConditions, err)
+
+	// conditions that match all the rows
+	_, _, err = BuildDeleteQuery("sessions", NotIn("id", []int{}))
+	assert.Equal(t, ErrMatchesAll, err)
+
+	_, _, err = BuildDeleteQuery("sessions", Or(Eq("a", 1), NotIn("id")))
+	assert.Equal(t, ErrMatchesAll, err)
+
+	_, _, err = BuildDeleteQuery("sessions", And(NotIn("id"), NotIn("id_user")))
+	assert.Equal(t, ErrMatchesAll, err)
+
+	query, _, err = BuildDeleteQuery("sessions", And(Eq("a", 1), NotIn("id")))
+	if err != nil {
+		t.Errorf("BuildDeleteQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "DELETE FROM sessions WHERE a=? AND 1=1", query)
+	}
+
+	_, _, err = BuildDeleteQuery("", User{ID: 1})
+	assert.Equal(t, ErrNoTable, err)
+
+	_, _, err = BuildDeleteQuery("users", 145)
+	if err == nil {
+		t.Errorf("BuildDeleteQuery() should have returned an error")
+	}
+}
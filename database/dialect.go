@@ -0,0 +1,228 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dialect holds the SQL syntax differences between databases
+type Dialect interface {
+	// Name of the dialect (ie: "mysql")
+	Name() string
+
+	// Quote quotes an identifier; qualified names (ie: "u.name") are quoted by part
+	Quote(identifier string) string
+
+	// Placeholder returns the parameter placeholder for the argument at index (starting at 1)
+	Placeholder(index int) string
+
+	// LimitOffset returns the LIMIT/OFFSET clause, or an empty string when both are zero
+	LimitOffset(limit, offset int) string
+
+	// QuoteString quotes and escapes a string literal
+	QuoteString(value string) string
+
+	// SupportsReturning is true if INSERT/UPDATE/DELETE support a RETURNING clause
+	SupportsReturning() bool
+}
+
+// Supported dialects
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+var (
+	defaultDialect      = MySQL
+	defaultDialectMutex sync.RWMutex
+)
+
+// SetDefaultDialect sets the dialect used when none is selected for a builder (MySQL by default)
+func SetDefaultDialect(d Dialect) {
+	defaultDialectMutex.Lock()
+	defer defaultDialectMutex.Unlock()
+	defaultDialect = d
+}
+
+// DefaultDialect returns the dialect used when none is selected for a builder
+func DefaultDialect() Dialect {
+	defaultDialectMutex.RLock()
+	defer defaultDialectMutex.RUnlock()
+	return defaultDialect
+}
+
+// DialectByName returns the dialect for a driver name (mysql, postgres/pgx, sqlite3)
+func DialectByName(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "mysql":
+		return MySQL, nil
+	case "postgres", "postgresql", "pgx":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	default:
+		return nil, fmt.Errorf("unsupported dialect '%s'", name)
+	}
+}
+
+// Rebind replaces the '?' placeholders of a query with the ones of the dialect (ie: "$1");
+// question marks inside quoted strings or identifiers, and inside comments (-- and /* */), are kept.
+//
+// The WHERE and SET fragments, and the statements built from them (ie: BuildDeleteQuery), always
+// use '?' so they can be composed; rebind the final query when the database needs other placeholders.
+func Rebind(d Dialect, query string) string {
+
+	if d == nil || d.Placeholder(1) == "?" {
+		return query
+	}
+
+	buf := new(bytes.Buffer)
+	var quote byte
+	var lineComment, blockComment bool
+	index := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case lineComment:
+			lineComment = c != '\n'
+		case blockComment:
+			if c == '*' && i+1 < len(query) && query[i+1] == '/' {
+				blockComment = false
+				buf.WriteString("*/")
+				i++
+				continue
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			lineComment = true
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			blockComment = true
+			buf.WriteString("/*")
+			i++
+			continue
+		case c == '?':
+			index++
+			buf.WriteString(d.Placeholder(index))
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	return buf.String()
+}
+
+// quotes every part of a qualified identifier, escaping the quote char by doubling it
+func quoteIdentifier(identifier string, quote string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// escapes the quotes of a string literal by doubling them (standard SQL)
+func quoteStringStandard(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// mysql interprets backslash escapes in string literals, unless the server runs with
+// NO_BACKSLASH_ESCAPES; quotes are doubled instead of escaped with a backslash, so the
+// literal can't be closed early in either mode
+var mysqlStringEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "''",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Quote(identifier string) string { return quoteIdentifier(identifier, "`") }
+
+func (mysqlDialect) Placeholder(index int) string { return "?" }
+
+func (mysqlDialect) LimitOffset(limit, offset int) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		// mysql doesn't support OFFSET without LIMIT
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	default:
+		return ""
+	}
+}
+
+func (mysqlDialect) QuoteString(value string) string {
+	return "'" + mysqlStringEscaper.Replace(value) + "'"
+}
+
+func (mysqlDialect) SupportsReturning() bool { return false }
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Quote(identifier string) string { return quoteIdentifier(identifier, `"`) }
+
+func (postgresDialect) Placeholder(index int) string { return "$" + strconv.Itoa(index) }
+
+func (postgresDialect) LimitOffset(limit, offset int) string {
+	var clauses []string
+	if limit > 0 {
+		clauses = append(clauses, fmt.Sprintf("LIMIT %d", limit))
+	}
+	if offset > 0 {
+		clauses = append(clauses, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(clauses, " ")
+}
+
+func (postgresDialect) QuoteString(value string) string { return quoteStringStandard(value) }
+
+func (postgresDialect) SupportsReturning() bool { return true }
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite3" }
+
+func (sqliteDialect) Quote(identifier string) string { return quoteIdentifier(identifier, `"`) }
+
+func (sqliteDialect) Placeholder(index int) string { return "?" }
+
+func (sqliteDialect) LimitOffset(limit, offset int) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		// sqlite doesn't support OFFSET without LIMIT
+		return fmt.Sprintf("LIMIT -1 OFFSET %d", offset)
+	default:
+		return ""
+	}
+}
+
+func (sqliteDialect) QuoteString(value string) string { return quoteStringStandard(value) }
+
+// RETURNING is supported since sqlite 3.35
+func (sqliteDialect) SupportsReturning() bool { return true }
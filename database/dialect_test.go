@@ -0,0 +1,78 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for the dialects
+func TestDialects(t *testing.T) {
+
+	assert.Equal(t, "`u`.`name`", MySQL.Quote("u.name"))
+	assert.Equal(t, `"u"."na""me"`, Postgres.Quote(`u.na"me`))
+	assert.Equal(t, `"users".*`, SQLite.Quote("users.*"))
+
+	assert.Equal(t, `'it''s a \\ test\n'`, MySQL.QuoteString("it's a \\ test\n"))
+
+	// the literal can't be closed with a backslash, with or without NO_BACKSLASH_ESCAPES
+	assert.Equal(t, `'a\\'' OR 1=1 -- '`, MySQL.QuoteString(`a\' OR 1=1 -- `))
+	assert.Equal(t, `'it''s a \ test'`, Postgres.QuoteString(`it's a \ test`))
+
+	assert.Equal(t, "?", MySQL.Placeholder(3))
+	assert.Equal(t, "$3", Postgres.Placeholder(3))
+
+	assert.Equal(t, "LIMIT 10 OFFSET 20", MySQL.LimitOffset(10, 20))
+	assert.Equal(t, "LIMIT 18446744073709551615 OFFSET 20", MySQL.LimitOffset(0, 20))
+	assert.Equal(t, "OFFSET 20", Postgres.LimitOffset(0, 20))
+	assert.Equal(t, "LIMIT -1 OFFSET 20", SQLite.LimitOffset(0, 20))
+	assert.Equal(t, "", SQLite.LimitOffset(0, 0))
+
+	d, err := DialectByName("pgx")
+	assert.Nil(t, err)
+	assert.Equal(t, Postgres, d)
+
+	_, err = DialectByName("oracle")
+	if err == nil {
+		t.Errorf("DialectByName() should have returned an error")
+	}
+}
+
+// test cases for Rebind()
+func TestRebind(t *testing.T) {
+
+	query := "SELECT * FROM users WHERE id_user=? AND name<>'who?' AND \"col?\"=? AND email IN (?,?)"
+
+	assert.Equal(t, query, Rebind(MySQL, query))
+	assert.Equal(t, "SELECT * FROM users WHERE id_user=$1 AND name<>'who?' AND \"col?\"=$2 AND email IN ($3,$4)",
+		Rebind(Postgres, query))
+
+	// comments don't shift the placeholders
+	query = "SELECT * FROM users -- who?\nWHERE id_user=? /* and? */ AND name=?"
+	assert.Equal(t, "SELECT * FROM users -- who?\nWHERE id_user=$1 /* and? */ AND name=$2", Rebind(Postgres, query))
+	assert.Equal(t, "SELECT $1-$2 FROM t WHERE a='--?' /*/ ? */ AND b=$3", Rebind(Postgres, "SELECT ?-? FROM t WHERE a='--?' /*/ ? */ AND b=?"))
+}
+
+// test cases for builders using the dialects
+func TestBuildersWithDialect(t *testing.T) {
+
+	query, _, err := Select(nil).From("users").Where(Eq("id_user", 1), Gt("age", 18)).Offset(10).Dialect(Postgres).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id_user=$1 AND age>$2 OFFSET 10", query)
+
+	// default dialect
+	SetDefaultDialect(Postgres)
+	defer SetDefaultDialect(MySQL)
+
+	name := "John"
+	set, err := BuildUpdateSetQuery(User{Name: &name}, []string{"Name"})
+	assert.Nil(t, err)
+	assert.Equal(t, `SET "name"='John'`, set)
+
+	fields, err := GetAllFields(User{}, []string{"ID", "Name", "Email", "Address", "Password", "City"}, true, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `"country","active"`, fields)
+
+	query, _, _ = Select(nil).From("users").Where(Eq("id_user", 1)).Build()
+	assert.Equal(t, "SELECT * FROM users WHERE id_user=$1", query)
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"reflect"
+)
+
+// DiffFields returns the names of the mapped fields whose values are different in original
+// and modified (structs or pointers to structs), ready to be used with the SET builders:
+//
+//	fields := database.DiffFields(original, user)
+//	set, args, err := database.BuildParametrizedUpdateSet(user, fields, user.ID)
+//
+// Pointers are compared by the pointed values and types with an Equal method (ie: time.Time)
+// are compared with it. Fields marked with a dash (`db:"-"`) are ignored.
+func DiffFields[T any](original, modified T) (fields []string) {
+
+	originalVal := reflect.Indirect(reflect.ValueOf(original))
+	modifiedVal := reflect.Indirect(reflect.ValueOf(modified))
+
+	if originalVal.Kind() != reflect.Struct || modifiedVal.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, field := range getTypeMeta(originalVal.Type()).columns {
+		index := field.field.Index
+		if !equalValues(originalVal.FieldByIndex(index), modifiedVal.FieldByIndex(index)) {
+			fields = append(fields, field.field.Name)
+		}
+	}
+
+	return
+}
+
+// compares two field values, following pointers
+func equalValues(a, b reflect.Value) bool {
+
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	// types like time.Time or decimals must be compared with their own method
+	if equal := a.MethodByName("Equal"); equal.IsValid() {
+		if equalType := equal.Type(); equalType.NumIn() == 1 && equalType.In(0) == a.Type() &&
+			equalType.NumOut() == 1 && equalType.Out(0).Kind() == reflect.Bool {
+			return equal.Call([]reflect.Value{b})[0].Bool()
+		}
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
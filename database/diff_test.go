@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Transfer struct {
+	ID        int               `db:"id_transfer"`
+	Status    string            `db:"status"`
+	Reference *string           `db:"reference"`
+	Metadata  map[string]string `db:"metadata" db_type:"json"`
+	UpdatedAt time.Time         `db:"updated_at"`
+	Notes     string            `db:"-"`
+}
+
+// test cases for DiffFields()
+func TestDiffFields(t *testing.T) {
+
+	ref1, ref2 := "ABC", "ABC"
+	now := time.Now()
+
+	original := Transfer{
+		ID:        1,
+		Status:    "pending",
+		Reference: &ref1,
+		Metadata:  map[string]string{"channel": "web"},
+		UpdatedAt: now,
+	}
+
+	// same values (different pointers and time locations)
+	modified := original
+	modified.Reference = &ref2
+	modified.Metadata = map[string]string{"channel": "web"}
+	modified.UpdatedAt = now.UTC()
+	modified.Notes = "ignored"
+	assert.Nil(t, DiffFields(original, modified))
+
+	// changed values
+	modified.Status = "approved"
+	modified.Reference = nil
+	modified.Metadata["channel"] = "app"
+	modified.UpdatedAt = now.Add(time.Second)
+	assert.Equal(t, []string{"Status", "Reference", "Metadata", "UpdatedAt"}, DiffFields(&original, &modified))
+
+	// ready to be used with the SET builders
+	set, err := BuildParametrizedUpdateSetQuery(modified, DiffFields(original, modified))
+	assert.Nil(t, err)
+	assert.Equal(t, "SET status=?,reference=?,metadata=?,updated_at=?", set)
+
+	assert.Nil(t, DiffFields("a", "b"))
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/astropay/go-tools/common"
+)
+
+// BuildInsertQuery returns a parametrized INSERT for all the db configured fields of obj (a struct
+// or pointer to struct) and its arguments. Those fields indicated in 'skipFields' (struct field
+// names) are not included; this is useful when dealing with auto incremental fields.
+// Nil pointer fields are inserted as NULL and json fields (db_type:"json") are marshaled.
+// An empty table is resolved with TableName(obj).
+func BuildInsertQuery(table string, obj interface{}, skipFields ...string) (query string, args []interface{}, err error) {
+
+	if table == "" {
+		if table, err = TableName(obj); err != nil {
+			return
+		}
+	}
+
+	objVal := reflect.Indirect(reflect.ValueOf(obj))
+	if objVal.Kind() != reflect.Struct {
+		err = fmt.Errorf("invalid obj type '%s'", objVal.Kind().String())
+		return
+	}
+
+	var columns []string
+	for _, field := range getTypeMeta(objVal.Type()).columns {
+		if _, found := common.FindInStringArray(field.field.Name, skipFields); found {
+			continue
+		}
+
+		var value interface{}
+		if value, err = getFieldValue(field, objVal.FieldByIndex(field.field.Index)); err != nil {
+			return
+		}
+
+		columns = append(columns, field.column)
+		args = append(args, value)
+	}
+
+	if len(columns) == 0 {
+		err = ErrInvalidFieldList
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES (", table, strings.Join(columns, ",")))
+	buf.WriteString(strings.TrimSuffix(strings.Repeat("?,", len(columns)), ","))
+	buf.WriteString(")")
+
+	query = buf.String()
+	return
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for BuildInsertQuery()
+func TestBuildInsertQuery(t *testing.T) {
+
+	name := "John"
+	query, args, err := BuildInsertQuery("users", &User{ID: 145, Name: &name, Active: true}, "ID")
+	if err != nil {
+		t.Errorf("BuildInsertQuery() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "INSERT INTO users (name,email,address,password,city,country,active) VALUES (?,?,?,?,?,?,?)", query)
+		assert.Equal(t, []interface{}{"John", nil, nil, nil, nil, nil, true}, args)
+	}
+
+	_, _, err = BuildInsertQuery("", User{})
+	assert.Equal(t, ErrNoTable, err)
+
+	_, _, err = BuildInsertQuery("users", "John")
+	if err == nil {
+		t.Errorf("BuildInsertQuery() should have returned an error")
+	}
+}
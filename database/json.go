@@ -0,0 +1,42 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// marshals the value of a json column (db_type:"json"); nil pointers, maps and slices are NULL
+func marshalJSONValue(value reflect.Value) (interface{}, error) {
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json value: %s", err.Error())
+	}
+
+	return string(data), nil
+}
+
+// unmarshals the content of a json column into the field; NULL sets the zero value
+func unmarshalJSONValue(data []byte, field reflect.Value) error {
+
+	if data == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return fmt.Errorf("error unmarshaling json value: %s", err.Error())
+	}
+
+	field.Set(target.Elem())
+	return nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type Merchant struct {
+	ID       int64             `db:"id_merchant"`
+	Metadata map[string]string `db:"metadata" db_type:"json"`
+	Settings *merchantSettings `db:"settings" db_type:"json"`
+	Tags     []string          `db:"tags" db_type:"json"`
+}
+
+type merchantSettings struct {
+	Currency string `json:"currency"`
+	Refunds  bool   `json:"refunds"`
+}
+
+// test cases for json fields in the builders
+func TestJSONBuilders(t *testing.T) {
+
+	merchant := Merchant{
+		ID:       1,
+		Metadata: map[string]string{"channel": "web"},
+		Tags:     []string{"gaming"},
+	}
+
+	set, err := BuildUpdateSetQuery(merchant, []string{"Metadata", "Settings", "Tags"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SET `metadata`='{\"channel\":\"web\"}',`settings`=NULL,`tags`='[\"gaming\"]'", set)
+
+	params, err := GetParameterValues(merchant, []string{"Metadata", "Settings"}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`{"channel":"web"}`, nil, 1}, params)
+
+	_, args, err := BuildInsertQuery("merchants", merchant)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(1), `{"channel":"web"}`, nil, `["gaming"]`}, args)
+}
+
+// test cases for json fields written and scanned through the repository
+func TestJSONRepository(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	db.Exec("CREATE TABLE merchants (id_merchant INTEGER PRIMARY KEY, metadata TEXT, settings TEXT, tags TEXT)")
+
+	merchants, err := NewRepository[Merchant](db, "merchants", "id_merchant")
+	if err != nil {
+		t.Fatalf("NewRepository() returned an error: %s", err.Error())
+	}
+
+	ctx := context.Background()
+	merchant := &Merchant{
+		Metadata: map[string]string{"channel": "web"},
+		Settings: &merchantSettings{Currency: "USD", Refunds: true},
+	}
+
+	if err = merchants.Insert(ctx, merchant); err != nil {
+		t.Fatalf("Insert() returned an error: %s", err.Error())
+	}
+
+	merchant, err = merchants.GetByID(ctx, merchant.ID)
+	if err != nil {
+		t.Fatalf("GetByID() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, "web", merchant.Metadata["channel"])
+	assert.Equal(t, &merchantSettings{Currency: "USD", Refunds: true}, merchant.Settings)
+	assert.Nil(t, merchant.Tags)
+
+	// invalid json content
+	db.Exec("UPDATE merchants SET tags = 'gaming'")
+	_, err = merchants.GetByID(ctx, merchant.ID)
+	if err == nil {
+		t.Errorf("GetByID() should have returned an error")
+	}
+}
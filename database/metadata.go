@@ -0,0 +1,104 @@
+package database
+
+import (
+	"reflect"
+	"sync"
+)
+
+// cached metadata of the struct types used with the builders, so the fields and tags are
+// reflected only once per type
+var typeMetaCache sync.Map
+
+// metadata of a struct field
+type fieldMeta struct {
+	field    reflect.StructField
+	column   string
+	dbType   DBType
+	nullable bool
+
+	// exported, not blank and not marked with a dash (`db:"-"`)
+	mapped bool
+}
+
+// metadata of a struct type
+type typeMeta struct {
+	objType reflect.Type
+
+	// all the fields, in order
+	fields []*fieldMeta
+	byName map[string]*fieldMeta
+
+	// mapped fields, in order
+	columns  []*fieldMeta
+	byColumn map[string]*fieldMeta
+
+	// table name (see TableName)
+	table string
+}
+
+// returns the metadata of a struct type (or pointer to struct)
+func getTypeMeta(objType reflect.Type) *typeMeta {
+
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	if meta, found := typeMetaCache.Load(objType); found {
+		return meta.(*typeMeta)
+	}
+
+	meta := &typeMeta{
+		objType:  objType,
+		byName:   make(map[string]*fieldMeta, objType.NumField()),
+		byColumn: make(map[string]*fieldMeta, objType.NumField()),
+	}
+
+	for i := 0; i < objType.NumField(); i++ {
+		field := newFieldMeta(objType.Field(i))
+		meta.fields = append(meta.fields, field)
+		meta.byName[field.field.Name] = field
+
+		if field.mapped {
+			meta.columns = append(meta.columns, field)
+			meta.byColumn[field.column] = field
+		}
+
+		if table := field.field.Tag.Get("db_table"); table != "" && meta.table == "" {
+			meta.table = table
+		}
+	}
+
+	// the interface has priority over the tag
+	if reflect.PtrTo(objType).Implements(tableNamerType) {
+		if table := reflect.New(objType).Interface().(TableNamer).TableName(); table != "" {
+			meta.table = table
+		}
+	}
+
+	actual, _ := typeMetaCache.LoadOrStore(objType, meta)
+	return actual.(*typeMeta)
+}
+
+func newFieldMeta(field reflect.StructField) *fieldMeta {
+	return &fieldMeta{
+		field:    field,
+		column:   resolveColumnName(field),
+		dbType:   resolveColumnType(field),
+		nullable: field.Tag.Get("db_nullable") != "false",
+		mapped:   field.PkgPath == "" && field.Name != "_" && field.Tag.Get("db") != "-",
+	}
+}
+
+// returns the metadata of a field by its name; promoted fields of embedded structs are
+// resolved but not cached
+func (m *typeMeta) fieldByName(name string) (*fieldMeta, bool) {
+	if field, found := m.byName[name]; found {
+		return field, true
+	}
+
+	if field, found := m.objType.FieldByName(name); found {
+		return newFieldMeta(field), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for getTypeMeta()
+func TestGetTypeMeta(t *testing.T) {
+
+	meta := getTypeMeta(reflect.TypeOf(&taggedAccount{}))
+	assert.Equal(t, "accounts", meta.table)
+
+	// blank fields are not mapped
+	if assert.Len(t, meta.fields, 3) && assert.Len(t, meta.columns, 2) {
+		assert.Equal(t, "id_account", meta.columns[0].column)
+		assert.Equal(t, DbTypeVarchar, meta.columns[1].dbType)
+	}
+
+	field, found := meta.fieldByName("Status")
+	assert.True(t, found)
+	assert.Equal(t, meta.byColumn["status"], field)
+
+	_, found = meta.fieldByName("Balance")
+	assert.False(t, found)
+
+	contact := getTypeMeta(reflect.TypeOf(Contact{}))
+	assert.False(t, contact.byColumn["email"].nullable)
+	assert.True(t, contact.byColumn["phone"].nullable)
+
+	// the metadata is cached per type, even with concurrent calls
+	var wg sync.WaitGroup
+	metas := make([]*typeMeta, 10)
+	for i := range metas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			metas[i] = getTypeMeta(reflect.TypeOf(Merchant{}))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, m := range metas {
+		assert.True(t, m == metas[0])
+	}
+	assert.True(t, getTypeMeta(reflect.TypeOf(&Merchant{})) == metas[0])
+	assert.Equal(t, DbTypeJSON, metas[0].byColumn["metadata"].dbType)
+}
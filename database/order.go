@@ -0,0 +1,99 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Sorting errors
+var (
+	ErrInvalidSort = errors.New("invalid sort field")
+)
+
+// BuildOrderBy returns an ORDER BY clause for a client supplied sort expression, like "-created_at,amount"
+// (a leading '-' means descending, and '+' ascending). Every field must be a key of 'allowed', which maps
+// it to the column used in the query, so user input never reaches the SQL; see SortColumns to allow the
+// columns of a struct. An empty expression returns an empty clause.
+func BuildOrderBy(sortParam string, allowed map[string]string) (clause string, err error) {
+
+	sortParam = strings.TrimSpace(sortParam)
+	if sortParam == "" {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("ORDER BY ")
+	seen := make(map[string]bool)
+
+	for i, part := range strings.Split(sortParam, ",") {
+		part = strings.TrimSpace(part)
+
+		direction := ""
+		switch {
+		case strings.HasPrefix(part, "-"):
+			direction = " DESC"
+			part = part[1:]
+		case strings.HasPrefix(part, "+"):
+			part = part[1:]
+		}
+
+		column, found := allowed[part]
+		if !found || part == "" || column == "" {
+			err = fmt.Errorf("%w '%s'", ErrInvalidSort, part)
+			return
+		}
+
+		if seen[part] {
+			err = fmt.Errorf("%w '%s' (duplicated)", ErrInvalidSort, part)
+			return
+		}
+		seen[part] = true
+
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(column + direction)
+	}
+
+	clause = buf.String()
+	return
+}
+
+// SortColumns returns the mapped columns of obj (a struct or pointer to struct) to be used as the
+// allowed fields of BuildOrderBy, optionally limited to some of them
+func SortColumns(obj interface{}, columns ...string) map[string]string {
+
+	allowed := make(map[string]string)
+
+	objType := reflect.TypeOf(obj)
+	if objType == nil {
+		return allowed
+	}
+
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	if objType.Kind() != reflect.Struct {
+		return allowed
+	}
+
+	meta := getTypeMeta(objType)
+	if len(columns) == 0 {
+		for _, field := range meta.columns {
+			allowed[field.column] = field.column
+		}
+		return allowed
+	}
+
+	for _, column := range columns {
+		if _, found := meta.byColumn[column]; found {
+			allowed[column] = column
+		}
+	}
+
+	return allowed
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for BuildOrderBy()
+func TestBuildOrderBy(t *testing.T) {
+
+	allowed := map[string]string{"created_at": "t.created_at", "amount": "t.amount", "status": "t.status"}
+
+	clause, err := BuildOrderBy("-created_at, amount,+status", allowed)
+	assert.Nil(t, err)
+	assert.Equal(t, "ORDER BY t.created_at DESC,t.amount,t.status", clause)
+
+	clause, err = BuildOrderBy("  ", allowed)
+	assert.Nil(t, err)
+	assert.Equal(t, "", clause)
+
+	// injection attempts and unknown fields
+	for _, sortParam := range []string{"amount; DROP TABLE users", "-password", "amount,", "--amount", "amount,-amount"} {
+		_, err = BuildOrderBy(sortParam, allowed)
+		assert.True(t, errors.Is(err, ErrInvalidSort), sortParam)
+	}
+
+	_, err = BuildOrderBy("password", allowed)
+	assert.Equal(t, "invalid sort field 'password'", err.Error())
+}
+
+// test cases for SortColumns()
+func TestSortColumns(t *testing.T) {
+
+	allowed := SortColumns(&Transfer{})
+	assert.Equal(t, map[string]string{
+		"id_transfer": "id_transfer",
+		"status":      "status",
+		"reference":   "reference",
+		"metadata":    "metadata",
+		"updated_at":  "updated_at",
+	}, allowed)
+
+	allowed = SortColumns(Transfer{}, "status", "updated_at", "notes")
+	assert.Equal(t, map[string]string{"status": "status", "updated_at": "updated_at"}, allowed)
+
+	clause, err := BuildOrderBy("-updated_at", allowed)
+	assert.Nil(t, err)
+	assert.Equal(t, "ORDER BY updated_at DESC", clause)
+
+	assert.Empty(t, SortColumns(nil))
+	assert.Empty(t, SortColumns("status"))
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Repository errors
+var (
+	ErrNotFound = errors.New("record not found")
+)
+
+// DBTX is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Page selects a page of results; Number starts at 1. The zero value returns all the rows.
+type Page struct {
+	Number int
+	Size   int
+}
+
+// Repository implements the CRUD operations of a table mapped to the struct T, with all the
+// queries generated from the 'db' tags:
+//
+//	users, err := database.NewRepository[User](db, "users", "id_user")
+//	user, err := users.GetByID(ctx, 145)
+//	user.Status = "active"
+//	err = users.Update(ctx, user, "Status")
+type Repository[T any] struct {
+	db        DBTX
+	table     string
+	keyColumn string
+	keyField  *fieldMeta
+	dialect   Dialect
+}
+
+// NewRepository creates a repository for the table, where keyColumn is the primary key column;
+// an empty table is resolved from T (see TableName)
+func NewRepository[T any](db DBTX, table string, keyColumn string) (*Repository[T], error) {
+
+	objType := reflect.TypeOf((*T)(nil)).Elem()
+	if objType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid obj type '%s'", objType.Kind().String())
+	}
+
+	if table == "" {
+		var err error
+		if table, err = tableNameOf(objType); err != nil {
+			return nil, err
+		}
+	}
+
+	keyField, found := getTypeMeta(objType).byColumn[keyColumn]
+	if !found {
+		return nil, fmt.Errorf("invalid key column '%s'", keyColumn)
+	}
+
+	return &Repository[T]{db: db, table: table, keyColumn: keyColumn, keyField: keyField}, nil
+}
+
+// WithDialect returns a copy of the repository that builds the queries for the dialect; by
+// default the one set with SetDefaultDialect is used
+func (r *Repository[T]) WithDialect(d Dialect) *Repository[T] {
+	clone := *r
+	clone.dialect = d
+	return &clone
+}
+
+func (r *Repository[T]) getDialect() Dialect {
+	if r.dialect == nil {
+		return DefaultDialect()
+	}
+	return r.dialect
+}
+
+// GetByID returns the record with the key; ErrNotFound if it doesn't exist
+func (r *Repository[T]) GetByID(ctx context.Context, id interface{}) (*T, error) {
+
+	items, err := r.list(ctx, Eq(r.keyColumn, id), 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &items[0], nil
+}
+
+// Insert inserts the record. A zero key is left to the database (auto increment) and it's set
+// from the RETURNING clause if the dialect supports it, or from the last insert id for integer keys.
+func (r *Repository[T]) Insert(ctx context.Context, obj *T) error {
+
+	objVal := reflect.ValueOf(obj).Elem()
+	keyVal := objVal.FieldByIndex(r.keyField.field.Index)
+
+	var skipFields []string
+	if keyVal.IsZero() {
+		skipFields = append(skipFields, r.keyField.field.Name)
+	}
+
+	query, args, err := BuildInsertQuery(r.table, obj, skipFields...)
+	if err != nil {
+		return err
+	}
+
+	dialect := r.getDialect()
+	if keyVal.IsZero() && dialect.SupportsReturning() {
+		return r.insertReturning(ctx, Rebind(dialect, query+" RETURNING "+r.keyColumn), args, keyVal)
+	}
+
+	result, err := r.db.ExecContext(ctx, Rebind(dialect, query), args...)
+	if err != nil {
+		return err
+	}
+
+	if keyVal.IsZero() {
+		switch keyVal.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if id, err := result.LastInsertId(); err == nil {
+				keyVal.SetInt(id)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if id, err := result.LastInsertId(); err == nil {
+				keyVal.SetUint(uint64(id))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository[T]) insertReturning(ctx context.Context, query string, args []interface{}, keyVal reflect.Value) error {
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+
+	if err = rows.Scan(keyVal.Addr().Interface()); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// Update updates the dirty fields (struct field names) of the record; with no dirty fields, all
+// the fields but the key are updated
+func (r *Repository[T]) Update(ctx context.Context, obj *T, dirtyFields ...string) error {
+
+	objVal := reflect.ValueOf(obj).Elem()
+	meta := getTypeMeta(objVal.Type())
+
+	if len(dirtyFields) == 0 {
+		for _, field := range meta.columns {
+			if field != r.keyField {
+				dirtyFields = append(dirtyFields, field.field.Name)
+			}
+		}
+	}
+
+	sets := make([]string, len(dirtyFields))
+	args := make([]interface{}, 0, len(dirtyFields)+1)
+
+	for i, name := range dirtyFields {
+		field, exists := meta.fieldByName(name)
+		if !exists {
+			return fmt.Errorf("invalid field '%s'", name)
+		}
+
+		value, err := getFieldValue(field, objVal.FieldByIndex(field.field.Index))
+		if err != nil {
+			return err
+		}
+
+		sets[i] = field.column + "=?"
+		args = append(args, value)
+	}
+
+	key, err := getFieldValue(r.keyField, objVal.FieldByIndex(r.keyField.field.Index))
+	if err != nil {
+		return err
+	}
+
+	where, whereArgs := BuildWhereClause(Eq(r.keyColumn, key))
+	query := fmt.Sprintf("UPDATE %s SET %s %s", r.table, strings.Join(sets, ","), where)
+
+	_, err = r.db.ExecContext(ctx, Rebind(r.getDialect(), query), append(args, whereArgs...)...)
+	return err
+}
+
+// Delete deletes the record with the key; ErrNotFound if it doesn't exist
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+
+	query, args, err := BuildDeleteQuery(r.table, Eq(r.keyColumn, id))
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, Rebind(r.getDialect(), query), args...)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// List returns the records matching the condition (nil for all), sorted by key
+func (r *Repository[T]) List(ctx context.Context, where Condition, page Page) ([]T, error) {
+
+	var limit, offset int
+	if page.Size > 0 {
+		limit = page.Size
+		if page.Number > 1 {
+			offset = (page.Number - 1) * page.Size
+		}
+	}
+
+	return r.list(ctx, where, limit, offset)
+}
+
+func (r *Repository[T]) list(ctx context.Context, where Condition, limit, offset int) ([]T, error) {
+
+	query, args, err := Select(new(T)).
+		From(r.table).
+		Where(where).
+		OrderBy(r.keyColumn).
+		Limit(limit).
+		Offset(offset).
+		Dialect(r.getDialect()).
+		Build()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0)
+	err = ScanAll(rows, &items)
+	return items, err
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type repoUser struct {
+	ID      int64   `db:"id_user"`
+	Name    string  `db:"name"`
+	Email   *string `db:"email"`
+	Country string  `db:"country"`
+}
+
+// test cases for Repository
+func TestRepository(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	db.Exec("CREATE TABLE users (id_user INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, email TEXT, country TEXT)")
+
+	users, err := NewRepository[repoUser](db, "users", "id_user")
+	if err != nil {
+		t.Fatalf("NewRepository() returned an error: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	// insert
+	for _, name := range []string{"John", "Jane", "Jack"} {
+		user := &repoUser{Name: name, Country: "UY"}
+		if err = users.Insert(ctx, user); err != nil {
+			t.Fatalf("Insert() returned an error: %s", err.Error())
+		}
+		assert.True(t, user.ID > 0)
+	}
+
+	// key from the RETURNING clause
+	user := &repoUser{Name: "Jill", Country: "BR"}
+	assert.Nil(t, users.WithDialect(SQLite).Insert(ctx, user))
+	assert.Equal(t, int64(4), user.ID)
+	assert.Nil(t, users.Delete(ctx, 4))
+
+	// get & update
+	user, err = users.GetByID(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetByID() returned an error: %s", err.Error())
+	}
+	assert.Equal(t, "Jane", user.Name)
+	assert.Nil(t, user.Email)
+
+	email := "jane@mail.com"
+	user.Email = &email
+	user.Country = "AR"
+	assert.Nil(t, users.Update(ctx, user, "Email"))
+
+	user, _ = users.GetByID(ctx, 2)
+	assert.Equal(t, "jane@mail.com", *user.Email)
+	assert.Equal(t, "UY", user.Country)
+
+	user.Country = "AR"
+	assert.Nil(t, users.Update(ctx, user))
+
+	// list
+	list, err := users.List(ctx, Eq("country", "UY"), Page{})
+	assert.Nil(t, err)
+	assert.Len(t, list, 2)
+
+	list, _ = users.List(ctx, nil, Page{Number: 2, Size: 2})
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "Jack", list[0].Name)
+	}
+
+	// delete
+	assert.Nil(t, users.Delete(ctx, 1))
+	assert.Equal(t, ErrNotFound, users.Delete(ctx, 1))
+
+	_, err = users.GetByID(ctx, 1)
+	assert.Equal(t, ErrNotFound, err)
+
+	// errors
+	_, err = NewRepository[repoUser](db, "users", "id")
+	assert.Equal(t, "invalid key column 'id'", err.Error())
+
+	_, err = NewRepository[string](db, "users", "id")
+	if err == nil {
+		t.Errorf("NewRepository() should have returned an error")
+	}
+}
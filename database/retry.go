@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers and Postgres SQLSTATE codes of the errors that can be retried
+const (
+	mysqlDeadlock         = 1213
+	mysqlLockWaitTimeout  = 1205
+	pgSerializationFailed = "40001"
+	pgDeadlockDetected    = "40P01"
+)
+
+// RetryPolicy configures ExecWithRetry
+type RetryPolicy struct {
+	// MaxRetries is the max number of retries after the first attempt
+	MaxRetries int
+
+	// Backoff is the time to wait before the first retry; it doubles on each retry, with a random
+	// jitter so the conflicting transactions don't retry at the same time (default 50ms)
+	Backoff time.Duration
+
+	// MaxBackoff limits the wait between retries (default 2s)
+	MaxBackoff time.Duration
+
+	// Retryable decides if an error can be retried (default IsRetryable)
+	Retryable func(err error) bool
+
+	// OnRetry is called before waiting for each retry (ie: for metrics)
+	OnRetry func(attempt int, err error)
+}
+
+// DefaultRetryPolicy retries deadlocks and serialization failures up to 3 times
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3}
+
+// ExecWithRetry runs fn, usually a whole transaction, and runs it again while it fails with a
+// retryable error (ie: a deadlock), up to the policy limit. The last error is returned.
+//
+//	err := database.ExecWithRetry(ctx, func(ctx context.Context) error {
+//		return database.WithTransaction(ctx, db, func(tx *sql.Tx) error {
+//			...
+//		})
+//	}, database.DefaultRetryPolicy)
+func ExecWithRetry(ctx context.Context, fn func(ctx context.Context) error, policy RetryPolicy) (err error) {
+
+	if policy.Backoff <= 0 {
+		policy.Backoff = 50 * time.Millisecond
+	}
+
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 2 * time.Second
+	}
+
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+
+	backoff := policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		if err = fn(ctx); err == nil || attempt >= policy.MaxRetries || !policy.Retryable(err) {
+			return
+		}
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, err)
+		}
+
+		// wait between half and the whole backoff
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// IsRetryable returns true for the errors that can be solved by running the transaction again:
+// MySQL deadlocks (1213) and lock wait timeouts (1205), and Postgres serialization failures (40001)
+// and deadlocks (40P01). Postgres errors are detected by their SQLState() method (lib/pq and pgx).
+func IsRetryable(err error) bool {
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		return state == pgSerializationFailed || state == pgDeadlockDetected
+	}
+
+	return false
+}
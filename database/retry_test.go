@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string    { return "pq: error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+// test cases for IsRetryable()
+func TestIsRetryable(t *testing.T) {
+
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	assert.True(t, IsRetryable(fmt.Errorf("debit failed: %w", &mysql.MySQLError{Number: 1205})))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+
+	assert.True(t, IsRetryable(&pgError{"40001"}))
+	assert.True(t, IsRetryable(&pgError{"40P01"}))
+	assert.False(t, IsRetryable(&pgError{"23505"}))
+
+	assert.False(t, IsRetryable(errors.New("connection refused")))
+	assert.False(t, IsRetryable(nil))
+}
+
+// test cases for ExecWithRetry()
+func TestExecWithRetry(t *testing.T) {
+
+	ctx := context.Background()
+	deadlock := &mysql.MySQLError{Number: 1213}
+
+	// succeeds after some deadlocks
+	var attempts, retries int
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond, OnRetry: func(attempt int, err error) { retries = attempt }}
+
+	err := ExecWithRetry(ctx, func(ctx context.Context) error {
+		if attempts++; attempts < 3 {
+			return deadlock
+		}
+		return nil
+	}, policy)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, retries)
+
+	// gives up after the max retries
+	attempts = 0
+	err = ExecWithRetry(ctx, func(ctx context.Context) error {
+		attempts++
+		return deadlock
+	}, policy)
+
+	assert.Equal(t, deadlock, err)
+	assert.Equal(t, 4, attempts)
+
+	// other errors are not retried
+	attempts = 0
+	failure := errors.New("insufficient funds")
+	err = ExecWithRetry(ctx, func(ctx context.Context) error {
+		attempts++
+		return failure
+	}, policy)
+
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 1, attempts)
+
+	// stops waiting when the context is done
+	cancelled, cancel := context.WithCancel(ctx)
+	attempts = 0
+	err = ExecWithRetry(cancelled, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return deadlock
+	}, RetryPolicy{MaxRetries: 3, Backoff: time.Minute})
+
+	assert.Equal(t, deadlock, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Scan errors
+var (
+	ErrInvalidScanDest = errors.New("scan destination must be a pointer to struct")
+	ErrInvalidScanList = errors.New("scan destination must be a pointer to a slice of structs (or pointers to structs)")
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// ScanRow scans the current row (after calling rows.Next()) into dest, a pointer to struct.
+//
+// Columns are mapped to fields using the 'db' tag (or the lowercased field name); columns
+// without a field are ignored. NULL values are scanned as nil for pointer fields and as the
+// zero value for the rest; fields implementing sql.Scanner (ie: sql.NullString) get the raw value
+// and json fields (db_type:"json") are unmarshaled.
+func ScanRow(rows *sql.Rows, dest interface{}) error {
+
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() || destVal.Elem().Kind() != reflect.Struct {
+		return ErrInvalidScanDest
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	return scanStruct(rows, columns, getTypeMeta(destVal.Elem().Type()), destVal.Elem())
+}
+
+// ScanAll scans all the rows into destSlice, a pointer to a slice of structs or pointers to structs,
+// and closes the rows. See ScanRow for the mapping rules.
+func ScanAll(rows *sql.Rows, destSlice interface{}) error {
+
+	defer rows.Close()
+
+	sliceVal := reflect.ValueOf(destSlice)
+	if sliceVal.Kind() != reflect.Ptr || sliceVal.IsNil() || sliceVal.Elem().Kind() != reflect.Slice {
+		return ErrInvalidScanList
+	}
+	sliceVal = sliceVal.Elem()
+
+	elemType := sliceVal.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return ErrInvalidScanList
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	meta := getTypeMeta(elemType)
+
+	for rows.Next() {
+		item := reflect.New(elemType)
+		if err = scanStruct(rows, columns, meta, item.Elem()); err != nil {
+			return err
+		}
+
+		if isPtr {
+			sliceVal.Set(reflect.Append(sliceVal, item))
+		} else {
+			sliceVal.Set(reflect.Append(sliceVal, item.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanStruct(rows *sql.Rows, columns []string, meta *typeMeta, objVal reflect.Value) error {
+
+	targets := make([]interface{}, len(columns))
+
+	// non pointer fields are scanned through a pointer, so NULL values don't fail
+	nullables := make(map[*fieldMeta]reflect.Value)
+	jsonValues := make(map[*fieldMeta]*[]byte)
+
+	for i, col := range columns {
+		fieldMeta, found := meta.byColumn[col]
+		if !found {
+			targets[i] = new(interface{})
+			continue
+		}
+
+		if fieldMeta.dbType == DbTypeJSON {
+			jsonValues[fieldMeta] = new([]byte)
+			targets[i] = jsonValues[fieldMeta]
+			continue
+		}
+
+		field := objVal.FieldByIndex(fieldMeta.field.Index)
+		if field.Kind() == reflect.Ptr || reflect.PtrTo(field.Type()).Implements(scannerType) {
+			targets[i] = field.Addr().Interface()
+			continue
+		}
+
+		holder := reflect.New(reflect.PtrTo(field.Type()))
+		nullables[fieldMeta] = holder
+		targets[i] = holder.Interface()
+	}
+
+	if err := rows.Scan(targets...); err != nil {
+		return fmt.Errorf("error scanning row: %s", err.Error())
+	}
+
+	for fieldMeta, holder := range nullables {
+		if value := holder.Elem(); !value.IsNil() {
+			objVal.FieldByIndex(fieldMeta.field.Index).Set(value.Elem())
+		} else {
+			objVal.FieldByIndex(fieldMeta.field.Index).Set(reflect.Zero(value.Type().Elem()))
+		}
+	}
+
+	for fieldMeta, data := range jsonValues {
+		if err := unmarshalJSONValue(*data, objVal.FieldByIndex(fieldMeta.field.Index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
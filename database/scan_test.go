@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type scanUser struct {
+	ID      int            `db:"id_user"`
+	Name    string         `db:"name"`
+	Email   *string        `db:"email"`
+	City    sql.NullString `db:"city"`
+	Active  bool
+	Ignored string `db:"-"`
+}
+
+func openScanDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+
+	db.Exec("CREATE TABLE users (id_user INTEGER PRIMARY KEY, name TEXT, email TEXT, city TEXT, active BOOLEAN, created_at TEXT)")
+	db.Exec("INSERT INTO users VALUES (1, 'John', 'john@mail.com', 'Montevideo', 1, '2020-01-01'), (2, NULL, NULL, NULL, NULL, NULL)")
+	return db
+}
+
+// test cases for ScanRow()
+func TestScanRow(t *testing.T) {
+
+	db := openScanDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT * FROM users ORDER BY id_user")
+	if err != nil {
+		t.Fatalf("Error querying users: %s", err.Error())
+	}
+	defer rows.Close()
+
+	user := scanUser{Ignored: "keep"}
+	rows.Next()
+	if err = ScanRow(rows, &user); err != nil {
+		t.Fatalf("ScanRow() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, "John", user.Name)
+	assert.Equal(t, "john@mail.com", *user.Email)
+	assert.Equal(t, "Montevideo", user.City.String)
+	assert.True(t, user.Active)
+	assert.Equal(t, "keep", user.Ignored)
+
+	// NULL values
+	rows.Next()
+	if err = ScanRow(rows, &user); err != nil {
+		t.Fatalf("ScanRow() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, 2, user.ID)
+	assert.Equal(t, "", user.Name)
+	assert.Nil(t, user.Email)
+	assert.False(t, user.City.Valid)
+	assert.False(t, user.Active)
+
+	assert.Equal(t, ErrInvalidScanDest, ScanRow(rows, user))
+}
+
+// test cases for ScanAll()
+func TestScanAll(t *testing.T) {
+
+	db := openScanDB(t)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id_user, name FROM users ORDER BY id_user")
+	if err != nil {
+		t.Fatalf("Error querying users: %s", err.Error())
+	}
+
+	var users []*scanUser
+	if err = ScanAll(rows, &users); err != nil {
+		t.Fatalf("ScanAll() returned an error: %s", err.Error())
+	}
+
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, "John", users[0].Name)
+		assert.Equal(t, 2, users[1].ID)
+	}
+
+	rows, _ = db.Query("SELECT id_user FROM users")
+	var ids []scanUser
+	assert.Nil(t, ScanAll(rows, &ids))
+	assert.Len(t, ids, 2)
+
+	rows, _ = db.Query("SELECT id_user FROM users")
+	assert.Equal(t, ErrInvalidScanList, ScanAll(rows, ids))
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SelectBuilder builds a parametrized SELECT statement; the projection defaults to the columns
+// of the struct (resolved from the 'db' tag), so reads stay consistent with the update helpers:
+//
+//	query, args, err := database.Select(User{}).
+//		From("users u").
+//		Join("addresses a", "a.id_user = u.id_user").
+//		Where(database.Eq("u.country", "UY")).
+//		OrderBy("u.name", "u.id_user DESC").
+//		Limit(20).
+//		Build()
+type SelectBuilder struct {
+	table      string
+	columns    []string
+	joins      []string
+	conditions []Condition
+	orderBy    []string
+	limit      int
+	offset     int
+	dialect    Dialect
+	err        error
+}
+
+// Select starts a SELECT builder with the columns of obj (a struct or pointer to struct); a nil
+// obj selects all the columns (*). Fields without the 'db' tag use the lowercased field name,
+// and fields marked with a dash (`db:"-"`) are ignored. The table is resolved with TableName(obj),
+// unless it's set with From.
+func Select(obj interface{}) *SelectBuilder {
+
+	sb := new(SelectBuilder)
+	if obj == nil {
+		return sb
+	}
+
+	sb.table, _ = TableName(obj)
+
+	objType := reflect.TypeOf(obj)
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	if objType.Kind() != reflect.Struct {
+		sb.err = fmt.Errorf("invalid obj type '%s'", objType.Kind().String())
+		return sb
+	}
+
+	for _, field := range getTypeMeta(objType).columns {
+		sb.columns = append(sb.columns, field.column)
+	}
+
+	return sb
+}
+
+// From sets the table (with an optional alias, ie: "users u")
+func (sb *SelectBuilder) From(table string) *SelectBuilder {
+	sb.table = table
+	return sb
+}
+
+// Columns replaces the projection (ie: "u.id_user", "COUNT(*) AS total")
+func (sb *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	sb.columns = columns
+	return sb
+}
+
+// Join adds an INNER JOIN
+func (sb *SelectBuilder) Join(table string, on string) *SelectBuilder {
+	sb.joins = append(sb.joins, fmt.Sprintf("JOIN %s ON %s", table, on))
+	return sb
+}
+
+// LeftJoin adds a LEFT JOIN
+func (sb *SelectBuilder) LeftJoin(table string, on string) *SelectBuilder {
+	sb.joins = append(sb.joins, fmt.Sprintf("LEFT JOIN %s ON %s", table, on))
+	return sb
+}
+
+// Where adds conditions, joined with AND to the previous ones
+func (sb *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	sb.conditions = append(sb.conditions, conditions...)
+	return sb
+}
+
+// OrderBy adds sort expressions (ie: "name", "created_at DESC")
+func (sb *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	sb.orderBy = append(sb.orderBy, columns...)
+	return sb
+}
+
+// Dialect sets the dialect used to build the query (placeholders and LIMIT syntax); by default
+// the one set with SetDefaultDialect is used
+func (sb *SelectBuilder) Dialect(d Dialect) *SelectBuilder {
+	sb.dialect = d
+	return sb
+}
+
+// Limit sets the max number of rows; zero means no limit
+func (sb *SelectBuilder) Limit(limit int) *SelectBuilder {
+	sb.limit = limit
+	return sb
+}
+
+// Offset sets the number of rows to skip
+func (sb *SelectBuilder) Offset(offset int) *SelectBuilder {
+	sb.offset = offset
+	return sb
+}
+
+// Build returns the query and its arguments
+func (sb *SelectBuilder) Build() (query string, args []interface{}, err error) {
+
+	if sb.err != nil {
+		err = sb.err
+		return
+	}
+
+	if sb.table == "" {
+		err = ErrNoTable
+		return
+	}
+
+	if sb.limit < 0 || sb.offset < 0 {
+		err = fmt.Errorf("invalid limit %d or offset %d", sb.limit, sb.offset)
+		return
+	}
+
+	columns := "*"
+	if len(sb.columns) > 0 {
+		columns = strings.Join(sb.columns, ",")
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s", columns, sb.table))
+
+	for _, join := range sb.joins {
+		buf.WriteString(" " + join)
+	}
+
+	var where string
+	if where, args = BuildWhereClause(sb.conditions...); where != "" {
+		buf.WriteString(" " + where)
+	}
+
+	if len(sb.orderBy) > 0 {
+		buf.WriteString(" ORDER BY " + strings.Join(sb.orderBy, ","))
+	}
+
+	dialect := sb.dialect
+	if dialect == nil {
+		dialect = DefaultDialect()
+	}
+
+	if limit := dialect.LimitOffset(sb.limit, sb.offset); limit != "" {
+		buf.WriteString(" " + limit)
+	}
+
+	query = Rebind(dialect, buf.String())
+	return
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for Select()
+func TestSelect(t *testing.T) {
+
+	// projection from the struct tags
+	query, args, err := Select(&User{}).
+		From("users").
+		Where(Eq("country", "UY"), IsNull("address")).
+		OrderBy("name", "id_user DESC").
+		Limit(20).
+		Offset(40).
+		Build()
+
+	if err != nil {
+		t.Errorf("Select().Build() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SELECT id_user,name,email,address,password,city,country,active FROM users "+
+			"WHERE country=? AND address IS NULL ORDER BY name,id_user DESC LIMIT 20 OFFSET 40", query)
+		assert.Equal(t, []interface{}{"UY"}, args)
+	}
+
+	// explicit projection and joins
+	query, args, err = Select(nil).
+		Columns("u.id_user", "COUNT(o.id_order) AS orders").
+		From("users u").
+		LeftJoin("orders o", "o.id_user = u.id_user").
+		Where(In("u.id_user", 1, 2)).
+		Build()
+
+	if err != nil {
+		t.Errorf("Select().Build() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "SELECT u.id_user,COUNT(o.id_order) AS orders FROM users u "+
+			"LEFT JOIN orders o ON o.id_user = u.id_user WHERE u.id_user IN (?,?)", query)
+		assert.Equal(t, []interface{}{1, 2}, args)
+	}
+
+	query, _, _ = Select(nil).From("users").Build()
+	assert.Equal(t, "SELECT * FROM users", query)
+
+	// errors
+	_, _, err = Select(User{}).Build()
+	assert.Equal(t, ErrNoTable, err)
+
+	_, _, err = Select("users").From("users").Build()
+	if err == nil {
+		t.Errorf("Select().Build() should have returned an error")
+	}
+}
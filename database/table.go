@@ -0,0 +1,58 @@
+package database
+
+import (
+	"reflect"
+)
+
+// TableNamer is implemented by the structs that know their table name
+type TableNamer interface {
+	TableName() string
+}
+
+var tableNamerType = reflect.TypeOf((*TableNamer)(nil)).Elem()
+
+// TableName returns the table of obj (a struct or pointer to struct), from the TableNamer interface
+// or from a 'db_table' tag in any of its fields, usually a blank one:
+//
+//	type User struct {
+//		_    struct{} `db_table:"users"`
+//		ID   int      `db:"id_user"`
+//		Name string   `db:"name"`
+//	}
+//
+// It returns ErrNoTable when the table can't be resolved.
+func TableName(obj interface{}) (table string, err error) {
+
+	if namer, ok := obj.(TableNamer); ok {
+		if table = namer.TableName(); table != "" {
+			return
+		}
+	}
+
+	if obj == nil {
+		err = ErrNoTable
+		return
+	}
+
+	return tableNameOf(reflect.TypeOf(obj))
+}
+
+// resolves the table of a struct type (or pointer to struct); the TableNamer implementation
+// is called on a zero value
+func tableNameOf(objType reflect.Type) (table string, err error) {
+
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	if objType.Kind() != reflect.Struct {
+		err = ErrNoTable
+		return
+	}
+
+	if table = getTypeMeta(objType).table; table == "" {
+		err = ErrNoTable
+	}
+
+	return
+}
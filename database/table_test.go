@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taggedAccount struct {
+	_      struct{} `db_table:"accounts"`
+	ID     int      `db:"id_account"`
+	Status string   `db:"status"`
+}
+
+type namedAccount struct {
+	ID int `db:"id_account"`
+}
+
+func (namedAccount) TableName() string {
+	return "legacy_accounts"
+}
+
+// test cases for TableName()
+func TestTableName(t *testing.T) {
+
+	table, err := TableName(taggedAccount{})
+	assert.Nil(t, err)
+	assert.Equal(t, "accounts", table)
+
+	table, err = TableName(&namedAccount{})
+	assert.Nil(t, err)
+	assert.Equal(t, "legacy_accounts", table)
+
+	_, err = TableName(User{})
+	assert.Equal(t, ErrNoTable, err)
+
+	_, err = TableName(nil)
+	assert.Equal(t, ErrNoTable, err)
+}
+
+// test cases for the builders resolving the table
+func TestBuildersWithTableName(t *testing.T) {
+
+	query, _, err := Select(taggedAccount{}).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT id_account,status FROM accounts", query)
+
+	query, _, err = BuildInsertQuery("", &taggedAccount{ID: 1, Status: "active"})
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO accounts (id_account,status) VALUES (?,?)", query)
+
+	query, _, err = BuildDeleteQuery("", namedAccount{ID: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM legacy_accounts WHERE id_account=?", query)
+
+	query, _, err = BuildBatchUpdateQuery("", []taggedAccount{{ID: 1}}, "ID", []string{"Status"})
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE accounts SET status=CASE id_account WHEN ? THEN ? END WHERE id_account IN (?)", query)
+
+	fields, err := GetAllFields(taggedAccount{}, nil, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "id_account,status", fields)
+
+	_, err = NewRepository[taggedAccount](nil, "", "id_account")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Tracking errors
+var (
+	ErrNoChanges = errors.New("entity has no changes")
+	ErrNilEntity = errors.New("entity can't be nil")
+)
+
+// Tracked wraps an entity with a snapshot of its values when it was loaded, so the changes
+// made since then are found automatically:
+//
+//	user, err := database.Track(loadedUser)
+//	user.Entity().Status = "blocked"
+//	query, args, err := user.BuildUpdate("", database.Eq("id_user", loadedUser.ID))
+//	...
+//	user.Snapshot()
+type Tracked[T any] struct {
+	entity   *T
+	original T
+	marked   map[string]bool
+}
+
+// Track starts tracking the changes of entity (a pointer to struct)
+func Track[T any](entity *T) (*Tracked[T], error) {
+
+	if entity == nil {
+		return nil, ErrNilEntity
+	}
+
+	if objType := reflect.TypeOf(entity).Elem(); objType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid obj type '%s'", objType.Kind().String())
+	}
+
+	t := &Tracked[T]{entity: entity}
+	t.Snapshot()
+	return t, nil
+}
+
+// Entity returns the tracked entity, to be modified in place
+func (t *Tracked[T]) Entity() *T {
+	return t.entity
+}
+
+// Original returns a copy of the entity values at the last snapshot
+func (t *Tracked[T]) Original() T {
+	return deepCopy(reflect.ValueOf(t.original)).Interface().(T)
+}
+
+// MarkDirty forces fields (struct field names) to be updated, even if their values didn't change;
+// fields that don't exist or aren't mapped to a column (`db:"-"`) are an error, and none is marked
+func (t *Tracked[T]) MarkDirty(fields ...string) error {
+
+	meta := getTypeMeta(reflect.TypeOf(t.entity).Elem())
+	for _, name := range fields {
+		if field, exists := meta.fieldByName(name); !exists || !field.mapped {
+			return fmt.Errorf("invalid field '%s'", name)
+		}
+	}
+
+	for _, name := range fields {
+		t.marked[name] = true
+	}
+	return nil
+}
+
+// DirtyFields returns the names of the fields changed since the last snapshot, in the struct order
+func (t *Tracked[T]) DirtyFields() (fields []string) {
+
+	changed := make(map[string]bool, len(t.marked))
+	for _, field := range DiffFields(t.original, *t.entity) {
+		changed[field] = true
+	}
+
+	// keep the struct order for the changed and marked fields
+	for _, field := range getTypeMeta(reflect.TypeOf(t.entity)).fields {
+		if name := field.field.Name; changed[name] || t.marked[name] {
+			fields = append(fields, name)
+		}
+	}
+
+	return
+}
+
+// IsDirty returns true if the entity changed since the last snapshot
+func (t *Tracked[T]) IsDirty() bool {
+	return len(t.DirtyFields()) > 0
+}
+
+// Snapshot takes the current values as the original ones (ie: after saving the entity)
+func (t *Tracked[T]) Snapshot() {
+	t.original = deepCopy(reflect.ValueOf(t.entity).Elem()).Interface().(T)
+	t.marked = make(map[string]bool)
+}
+
+// BuildUpdate returns a parametrized UPDATE of the dirty fields and its arguments; an empty table
+// is resolved with TableName. At least one condition that limits the rows is required (see
+// BuildDeleteQuery), and ErrNoChanges is returned when there is nothing to update.
+func (t *Tracked[T]) BuildUpdate(table string, conditions ...Condition) (query string, args []interface{}, err error) {
+
+	if table == "" {
+		if table, err = TableName(t.entity); err != nil {
+			return
+		}
+	}
+
+	fields := t.DirtyFields()
+	if len(fields) == 0 {
+		err = ErrNoChanges
+		return
+	}
+
+	where, whereArgs := BuildWhereClause(conditions...)
+	if where == "" {
+		err = ErrNoConditions
+		return
+	}
+
+	if matchesAll(And(conditions...)) {
+		err = ErrMatchesAll
+		return
+	}
+
+	var set string
+	if set, args, err = BuildParametrizedUpdateSet(t.entity, fields, whereArgs...); err != nil {
+		return
+	}
+
+	query = fmt.Sprintf("UPDATE %s %s %s", table, set, where)
+	return
+}
+
+// UpdateTracked updates the dirty fields of a tracked entity and takes a new snapshot; it does
+// nothing if there are no changes
+func (r *Repository[T]) UpdateTracked(ctx context.Context, tracked *Tracked[T]) error {
+
+	fields := tracked.DirtyFields()
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if err := r.Update(ctx, tracked.Entity(), fields...); err != nil {
+		return err
+	}
+
+	tracked.Snapshot()
+	return nil
+}
+
+// copies a value following pointers, maps, slices and the exported fields of structs, so
+// the snapshot doesn't share memory with the entity
+func deepCopy(value reflect.Value) reflect.Value {
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(deepCopy(value.Elem()))
+		return copied
+
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(deepCopy(value.Elem()))
+		return copied
+
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		for _, key := range value.MapKeys() {
+			copied.SetMapIndex(key, deepCopy(value.MapIndex(key)))
+		}
+		return copied
+
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i)))
+		}
+		return copied
+
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(deepCopy(value.Field(i)))
+			}
+		}
+		return copied
+
+	default:
+		return value
+	}
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for Tracked
+func TestTracked(t *testing.T) {
+
+	ref := "ABC"
+	transfer := &Transfer{ID: 1, Status: "pending", Reference: &ref, Metadata: map[string]string{"channel": "web"}}
+
+	tracked, err := Track(transfer)
+	if err != nil {
+		t.Fatalf("Track() returned an error: %s", err.Error())
+	}
+	assert.False(t, tracked.IsDirty())
+
+	// changes inside pointers and maps are detected
+	*transfer.Reference = "XYZ"
+	transfer.Metadata["channel"] = "app"
+	assert.Equal(t, []string{"Reference", "Metadata"}, tracked.DirtyFields())
+	assert.Equal(t, "ABC", *tracked.Original().Reference)
+
+	tracked.Entity().Status = "approved"
+	assert.Nil(t, tracked.MarkDirty("ID"))
+	assert.Equal(t, []string{"ID", "Status", "Reference", "Metadata"}, tracked.DirtyFields())
+
+	query, args, err := tracked.BuildUpdate("transfers", Eq("id_transfer", 1))
+	if err != nil {
+		t.Errorf("BuildUpdate() returned an error: %s", err.Error())
+	} else {
+		assert.Equal(t, "UPDATE transfers SET id_transfer=?,status=?,reference=?,metadata=? WHERE id_transfer=?", query)
+		assert.Equal(t, []interface{}{1, "approved", "XYZ", `{"channel":"app"}`, 1}, args)
+	}
+
+	// unknown or unmapped fields can't be marked
+	assert.NotNil(t, tracked.MarkDirty("Status", "Unknown"))
+	assert.NotNil(t, tracked.MarkDirty("Notes"))
+	assert.Equal(t, []string{"ID", "Status", "Reference", "Metadata"}, tracked.DirtyFields())
+
+	_, _, err = tracked.BuildUpdate("transfers")
+	assert.Equal(t, ErrNoConditions, err)
+
+	_, _, err = tracked.BuildUpdate("transfers", NotIn("id"))
+	assert.Equal(t, ErrMatchesAll, err)
+
+	_, _, err = tracked.BuildUpdate("")
+	assert.Equal(t, ErrNoTable, err)
+
+	tracked.Snapshot()
+	assert.False(t, tracked.IsDirty())
+
+	_, _, err = tracked.BuildUpdate("transfers", Eq("id_transfer", 1))
+	assert.Equal(t, ErrNoChanges, err)
+}
+
+// test cases for Track() with invalid entities
+func TestTrackInvalid(t *testing.T) {
+
+	_, err := Track[Transfer](nil)
+	assert.Equal(t, ErrNilEntity, err)
+
+	_, err = Track(new(int))
+	assert.NotNil(t, err)
+}
+
+// test cases for Repository.UpdateTracked()
+func TestRepositoryUpdateTracked(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	db.Exec("CREATE TABLE users (id_user INTEGER PRIMARY KEY, name TEXT, email TEXT, country TEXT)")
+	db.Exec("INSERT INTO users VALUES (1, 'John', NULL, 'UY')")
+
+	users, _ := NewRepository[repoUser](db, "users", "id_user")
+	ctx := context.Background()
+
+	user, err := users.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetByID() returned an error: %s", err.Error())
+	}
+
+	tracked, err := Track(user)
+	if err != nil {
+		t.Fatalf("Track() returned an error: %s", err.Error())
+	}
+	assert.Nil(t, users.UpdateTracked(ctx, tracked))
+
+	// a concurrent change is not overwritten, since only the dirty fields are updated
+	db.Exec("UPDATE users SET country = 'AR' WHERE id_user = 1")
+
+	user.Name = "Johnny"
+	assert.Nil(t, users.UpdateTracked(ctx, tracked))
+	assert.False(t, tracked.IsDirty())
+
+	user, _ = users.GetByID(ctx, 1)
+	assert.Equal(t, "Johnny", user.Name)
+	assert.Equal(t, "AR", user.Country)
+}
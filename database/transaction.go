@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// TxBeginner is implemented by *sql.DB and *sql.Conn
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// TxOption configures a transaction started by WithTransaction
+type TxOption func(*sql.TxOptions)
+
+// Isolation sets the isolation level of the transaction (ie: sql.LevelSerializable)
+func Isolation(level sql.IsolationLevel) TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.Isolation = level
+	}
+}
+
+// ReadOnly starts a read only transaction
+func ReadOnly() TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.ReadOnly = true
+	}
+}
+
+// WithTransaction runs fn inside a transaction: it's committed if fn returns nil and rolled back
+// if fn returns an error or panics (the panic is raised again after the rollback).
+//
+//	err := database.WithTransaction(ctx, db, func(tx *sql.Tx) error {
+//		if _, err := tx.ExecContext(ctx, debitQuery, amount, from); err != nil {
+//			return err
+//		}
+//		_, err := tx.ExecContext(ctx, creditQuery, amount, to)
+//		return err
+//	}, database.Isolation(sql.LevelSerializable))
+func WithTransaction(ctx context.Context, db TxBeginner, fn func(tx *sql.Tx) error, options ...TxOption) (err error) {
+
+	opts := new(sql.TxOptions)
+	for _, option := range options {
+		option(opts)
+	}
+
+	var tx *sql.Tx
+	if tx, err = db.BeginTx(ctx, opts); err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = fmt.Errorf("%w (rollback failed: %s)", err, rollbackErr.Error())
+		}
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
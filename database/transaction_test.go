@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func openTxDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file::memory:?cache=shared")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+
+	// a single connection, so the in-memory database is shared
+	db.SetMaxOpenConns(1)
+	db.Exec("CREATE TABLE accounts (id INTEGER PRIMARY KEY, balance INTEGER)")
+	db.Exec("DELETE FROM accounts")
+	db.Exec("INSERT INTO accounts VALUES (1, 100), (2, 0)")
+	return db
+}
+
+func balance(db *sql.DB, id int) (balance int) {
+	db.QueryRow("SELECT balance FROM accounts WHERE id = ?", id).Scan(&balance)
+	return
+}
+
+// test cases for WithTransaction()
+func TestWithTransaction(t *testing.T) {
+
+	db := openTxDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	transfer := func(amount int, fail error) func(tx *sql.Tx) error {
+		return func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - ? WHERE id = 1", amount); err != nil {
+				return err
+			}
+			if fail != nil {
+				return fail
+			}
+			_, err := tx.ExecContext(ctx, "UPDATE accounts SET balance = balance + ? WHERE id = 2", amount)
+			return err
+		}
+	}
+
+	// commit
+	assert.Nil(t, WithTransaction(ctx, db, transfer(30, nil), Isolation(sql.LevelSerializable)))
+	assert.Equal(t, 70, balance(db, 1))
+	assert.Equal(t, 30, balance(db, 2))
+
+	// rollback on error
+	failure := errors.New("insufficient funds")
+	assert.Equal(t, failure, WithTransaction(ctx, db, transfer(30, failure)))
+	assert.Equal(t, 70, balance(db, 1))
+
+	// rollback on panic
+	func() {
+		defer func() {
+			assert.Equal(t, "boom", recover())
+		}()
+
+		WithTransaction(ctx, db, func(tx *sql.Tx) error {
+			transfer(30, nil)(tx)
+			panic("boom")
+		})
+	}()
+	assert.Equal(t, 70, balance(db, 1))
+	assert.Equal(t, 30, balance(db, 2))
+
+	// errors starting the transaction
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, WithTransaction(cancelled, db, transfer(30, nil), ReadOnly()))
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is a part of a WHERE clause, rendered as a parametrized expression and its arguments
+type Condition interface {
+	ToSQL() (expr string, args []interface{})
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+func (c comparison) ToSQL() (string, []interface{}) {
+	return fmt.Sprintf("%s%s?", c.column, c.operator), []interface{}{c.value}
+}
+
+// Eq is `column = value`
+func Eq(column string, value interface{}) Condition {
+	return comparison{column, "=", value}
+}
+
+// Neq is `column <> value`
+func Neq(column string, value interface{}) Condition {
+	return comparison{column, "<>", value}
+}
+
+// Gt is `column > value`
+func Gt(column string, value interface{}) Condition {
+	return comparison{column, ">", value}
+}
+
+// Gte is `column >= value`
+func Gte(column string, value interface{}) Condition {
+	return comparison{column, ">=", value}
+}
+
+// Lt is `column < value`
+func Lt(column string, value interface{}) Condition {
+	return comparison{column, "<", value}
+}
+
+// Lte is `column <= value`
+func Lte(column string, value interface{}) Condition {
+	return comparison{column, "<=", value}
+}
+
+// Like is `column LIKE pattern`
+func Like(column string, pattern string) Condition {
+	return comparison{column, " LIKE ", pattern}
+}
+
+type in struct {
+	column string
+	not    bool
+	values []interface{}
+}
+
+func (c in) ToSQL() (string, []interface{}) {
+	// an empty list never matches (or always matches, for NOT IN)
+	if len(c.values) == 0 {
+		if c.not {
+			return "1=1", nil
+		}
+		return "1=0", nil
+	}
+
+	operator := " IN "
+	if c.not {
+		operator = " NOT IN "
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(c.values)), ",")
+	return fmt.Sprintf("%s%s(%s)", c.column, operator, placeholders), c.values
+}
+
+// In is `column IN (values...)`; a single slice value is expanded
+func In(column string, values ...interface{}) Condition {
+	return in{column: column, values: expandValues(values)}
+}
+
+// NotIn is `column NOT IN (values...)`; a single slice value is expanded. An empty list matches
+// all the rows, so the DELETE and UPDATE builders reject it (ErrMatchesAll).
+func NotIn(column string, values ...interface{}) Condition {
+	return in{column: column, not: true, values: expandValues(values)}
+}
+
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+func (c between) ToSQL() (string, []interface{}) {
+	return fmt.Sprintf("%s BETWEEN ? AND ?", c.column), []interface{}{c.from, c.to}
+}
+
+// Between is `column BETWEEN from AND to`
+func Between(column string, from, to interface{}) Condition {
+	return between{column, from, to}
+}
+
+type isNull struct {
+	column string
+	not    bool
+}
+
+func (c isNull) ToSQL() (string, []interface{}) {
+	if c.not {
+		return c.column + " IS NOT NULL", nil
+	}
+	return c.column + " IS NULL", nil
+}
+
+// IsNull is `column IS NULL`
+func IsNull(column string) Condition {
+	return isNull{column: column}
+}
+
+// IsNotNull is `column IS NOT NULL`
+func IsNotNull(column string) Condition {
+	return isNull{column: column, not: true}
+}
+
+type group struct {
+	operator   string
+	conditions []Condition
+}
+
+func (g group) ToSQL() (string, []interface{}) {
+	var parts []string
+	var nested []bool
+	var args []interface{}
+
+	for _, cond := range g.conditions {
+		if cond == nil {
+			continue
+		}
+
+		expr, condArgs := cond.ToSQL()
+		if expr == "" {
+			continue
+		}
+
+		_, isGroup := cond.(group)
+		parts = append(parts, expr)
+		nested = append(nested, isGroup)
+		args = append(args, condArgs...)
+	}
+
+	// nested groups are wrapped so the precedence is kept
+	if len(parts) > 1 {
+		for i := range parts {
+			if nested[i] {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+	}
+
+	return strings.Join(parts, " "+g.operator+" "), args
+}
+
+// And joins the conditions with AND; nil conditions are ignored
+func And(conditions ...Condition) Condition {
+	return group{"AND", conditions}
+}
+
+// Or joins the conditions with OR; nil conditions are ignored
+func Or(conditions ...Condition) Condition {
+	return group{"OR", conditions}
+}
+
+// BuildWhereClause returns the parametrized WHERE clause (ie: "WHERE id_user=? AND status IN (?,?)")
+// for all the conditions joined with AND, and its arguments. It returns an empty clause when there
+// are no conditions, so it can be appended to a query built with the SET builders:
+//
+//	where, whereArgs := BuildWhereClause(Eq("id_user", user.ID))
+//	set, args, _ := BuildParametrizedUpdateSet(user, fields, whereArgs...)
+//	db.Exec("UPDATE users "+set+" "+where, args...)
+func BuildWhereClause(conditions ...Condition) (clause string, args []interface{}) {
+
+	var expr string
+	if expr, args = And(conditions...).ToSQL(); expr == "" {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("WHERE ")
+	buf.WriteString(expr)
+
+	clause = buf.String()
+	return
+}
+
+// returns true when the condition matches all the rows (ie: an empty NotIn, alone or in an OR),
+// so it doesn't limit a DELETE or UPDATE; custom conditions are assumed to limit them
+func matchesAll(cond Condition) bool {
+
+	switch c := cond.(type) {
+
+	case in:
+		return c.not && len(c.values) == 0
+
+	case group:
+		var parts int
+		for _, sub := range c.conditions {
+			if sub == nil {
+				continue
+			}
+			if expr, _ := sub.ToSQL(); expr == "" {
+				continue
+			}
+
+			parts++
+			all := matchesAll(sub)
+			if c.operator == "OR" && all {
+				return true
+			}
+			if c.operator == "AND" && !all {
+				return false
+			}
+		}
+		return c.operator == "AND" && parts > 0
+	}
+
+	return false
+}
+
+// expands a single slice argument into its items (but not []byte, which is a single value)
+func expandValues(values []interface{}) []interface{} {
+	if len(values) != 1 || values[0] == nil {
+		return values
+	}
+
+	val := reflect.ValueOf(values[0])
+	if val.Kind() != reflect.Slice || val.Type().Elem().Kind() == reflect.Uint8 {
+		return values
+	}
+
+	expanded := make([]interface{}, val.Len())
+	for i := range expanded {
+		expanded[i] = val.Index(i).Interface()
+	}
+	return expanded
+}
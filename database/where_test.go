@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test cases for BuildWhereClause()
+func TestBuildWhereClause(t *testing.T) {
+
+	clause, args := BuildWhereClause(
+		Eq("country", "UY"),
+		Neq("status", "deleted"),
+		In("id_user", []int{1, 2, 3}),
+		Or(Like("email", "%@astropay.com"), And(Gte("age", 18), IsNotNull("document"))),
+		Between("created_at", "2020-01-01", "2020-12-31"),
+	)
+
+	assert.Equal(t, "WHERE country=? AND status<>? AND id_user IN (?,?,?) AND "+
+		"(email LIKE ? OR (age>=? AND document IS NOT NULL)) AND created_at BETWEEN ? AND ?", clause)
+	assert.Equal(t, []interface{}{"UY", "deleted", 1, 2, 3, "%@astropay.com", 18, "2020-01-01", "2020-12-31"}, args)
+
+	// nil and empty conditions are ignored
+	clause, args = BuildWhereClause(nil, And(), Gt("amount", 100))
+	assert.Equal(t, "WHERE amount>?", clause)
+	assert.Equal(t, []interface{}{100}, args)
+
+	clause, args = BuildWhereClause()
+	assert.Equal(t, "", clause)
+	assert.Nil(t, args)
+
+	// empty IN lists
+	clause, _ = BuildWhereClause(In("id_user"), NotIn("status", []string{}))
+	assert.Equal(t, "WHERE 1=0 AND 1=1", clause)
+}
+
+// test cases for a full UPDATE composed with the SET builder
+func TestWhereWithUpdateSet(t *testing.T) {
+
+	name := "John"
+	user := User{ID: 145, Name: &name}
+	fields := []string{"Name"}
+
+	set, err := BuildParametrizedUpdateSetQuery(user, fields)
+	if err != nil {
+		t.Errorf("BuildParametrizedUpdateSetQuery() returned an error: %s", err.Error())
+		return
+	}
+
+	where, whereArgs := BuildWhereClause(Eq("id_user", user.ID))
+	args, err := GetParameterValues(user, fields, whereArgs...)
+	if err != nil {
+		t.Errorf("GetParameterValues() returned an error: %s", err.Error())
+		return
+	}
+
+	assert.Equal(t, "UPDATE users "+set+" WHERE id_user=?", "UPDATE users "+set+" "+where)
+	assert.Equal(t, []interface{}{"John", 145}, args)
+}
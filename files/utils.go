@@ -50,6 +50,16 @@ func DeleteFile(path string) (err error) {
 	return
 }
 
+// EnsureDir creates the directory indicated in path (and its parents) if it doesn't exist
+func EnsureDir(path string) (err error) {
+	if Exists(path) {
+		return
+	}
+
+	err = os.MkdirAll(path, 0755)
+	return
+}
+
 // GetAppPath returns the application absolute path
 func GetAppPath() string {
 	if dir, err := filepath.Abs(filepath.Dir(os.Args[0])); err == nil {
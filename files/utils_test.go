@@ -3,6 +3,7 @@ package files
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -23,3 +24,25 @@ func TestFileExists(t *testing.T) {
 		t.Error("Test failed checking a fake path")
 	}
 }
+
+func TestEnsureDir(t *testing.T) {
+	base, err := ioutil.TempDir(os.TempDir(), "test")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(base)
+
+	dir := filepath.Join(base, "a", "b")
+	if err = EnsureDir(dir); err != nil {
+		t.Errorf("EnsureDir() failed: %s", err.Error())
+	}
+
+	if !Exists(dir) {
+		t.Errorf("Directory '%s' is not present", dir)
+	}
+
+	// already exists
+	if err = EnsureDir(dir); err != nil {
+		t.Errorf("EnsureDir() failed with an existing directory: %s", err.Error())
+	}
+}
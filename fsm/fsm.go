@@ -0,0 +1,222 @@
+// Package fsm declares finite state machines (states, allowed transitions, guards and hooks)
+// to handle entity lifecycles, ie: payment statuses:
+//
+//	payments, err := fsm.New(fsm.Config{
+//		Name: "payment",
+//		Transitions: []fsm.Transition{
+//			{Event: "approve", From: []fsm.State{"pending"}, To: "approved", Guards: []fsm.Guard{hasFunds}},
+//			{Event: "reject", From: []fsm.State{"pending"}, To: "rejected"},
+//			{Event: "refund", From: []fsm.State{"approved"}, To: "refunded"},
+//		},
+//	})
+//
+//	err = payments.Fire(ctx, payment, "approve")
+//
+// Entities implement `Stateful`; each successful transition is notified to the listeners
+// (ie: to emit an audit event or to persist the new status with a `StatusUpdater`).
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FSM errors
+var (
+	ErrInvalidTransition = errors.New("transition not allowed from the current state")
+	ErrUnknownEvent      = errors.New("unknown event")
+	ErrDuplicatedEvent   = errors.New("event declared twice for the same source state")
+	ErrNoTransitions     = errors.New("state machine has no transitions")
+)
+
+// Event triggers a transition
+type Event string
+
+// Stateful is implemented by the entities handled by a state machine
+type Stateful interface {
+	State() State
+	SetState(state State)
+}
+
+// Guard decides if a transition can happen; returning an error rejects it
+type Guard func(ctx context.Context, entity Stateful, t TransitionEvent) error
+
+// Hook is invoked when entering or leaving a state; returning an error aborts the transition
+type Hook func(ctx context.Context, entity Stateful, t TransitionEvent) error
+
+// Listener is notified after each successful transition
+type Listener func(ctx context.Context, entity Stateful, t TransitionEvent)
+
+// Transition declares that `Event` moves the entity from any of the `From` states to `To`
+type Transition struct {
+	Event  Event
+	From   []State
+	To     State
+	Guards []Guard
+}
+
+// TransitionEvent describes a transition being executed
+type TransitionEvent struct {
+	Machine string
+	Event   Event
+	From    State
+	To      State
+	Args    []interface{}
+	At      time.Time
+}
+
+// GuardError is returned when a guard rejects a transition
+type GuardError struct {
+	Event Event
+	From  State
+	Err   error
+}
+
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("transition %s from %s rejected: %s", e.Event, e.From, e.Err.Error())
+}
+
+// Unwrap returns the error returned by the guard
+func (e *GuardError) Unwrap() error {
+	return e.Err
+}
+
+// Config declares the state machine
+type Config struct {
+	Name        string
+	Transitions []Transition
+
+	// OnEnter and OnExit are invoked when entering or leaving a state
+	OnEnter map[State][]Hook
+	OnExit  map[State][]Hook
+
+	Listeners []Listener
+}
+
+// Machine is a state machine definition; it doesn't hold state, so it can be shared to handle
+// any amount of entities
+type Machine struct {
+	config      Config
+	transitions map[Event]map[State]*Transition
+	states      map[State]bool
+}
+
+// New validates the config and creates the state machine
+func New(config Config) (m *Machine, err error) {
+	if len(config.Transitions) == 0 {
+		return nil, ErrNoTransitions
+	}
+
+	m = &Machine{
+		config:      config,
+		transitions: make(map[Event]map[State]*Transition),
+		states:      make(map[State]bool),
+	}
+
+	for i := range config.Transitions {
+		t := &config.Transitions[i]
+
+		if _, found := m.transitions[t.Event]; !found {
+			m.transitions[t.Event] = make(map[State]*Transition)
+		}
+
+		for _, from := range t.From {
+			if _, found := m.transitions[t.Event][from]; found {
+				return nil, fmt.Errorf("%w: %s from %s", ErrDuplicatedEvent, t.Event, from)
+			}
+			m.transitions[t.Event][from] = t
+			m.states[from] = true
+		}
+		m.states[t.To] = true
+	}
+
+	return
+}
+
+// Name returns the machine name
+func (m *Machine) Name() string {
+	return m.config.Name
+}
+
+// States returns all the states declared in the transitions
+func (m *Machine) States() (states []State) {
+	for state := range m.states {
+		states = append(states, state)
+	}
+	sortStates(states)
+	return
+}
+
+// AddListener adds a listener notified after each successful transition
+func (m *Machine) AddListener(listener Listener) {
+	m.config.Listeners = append(m.config.Listeners, listener)
+}
+
+// Can returns true if the event is allowed from the entity's current state (guards aren't evaluated)
+func (m *Machine) Can(entity Stateful, event Event) bool {
+	_, found := m.transitions[event][entity.State()]
+	return found
+}
+
+// AvailableEvents returns the events allowed from the state
+func (m *Machine) AvailableEvents(state State) (events []Event) {
+	for event, from := range m.transitions {
+		if _, found := from[state]; found {
+			events = append(events, event)
+		}
+	}
+	sortEvents(events)
+	return
+}
+
+// IsFinal returns true if no event is allowed from the state
+func (m *Machine) IsFinal(state State) bool {
+	return len(m.AvailableEvents(state)) == 0
+}
+
+// Fire executes the transition triggered by the event: it evaluates the guards, runs the exit
+// hooks of the current state, sets the new state, runs the entry hooks of the new state and
+// notifies the listeners. If an entry hook fails the previous state is restored.
+func (m *Machine) Fire(ctx context.Context, entity Stateful, event Event, args ...interface{}) error {
+	from, found := m.transitions[event]
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+	}
+
+	current := entity.State()
+	transition, found := from[current]
+	if !found {
+		return fmt.Errorf("%w: %s from %s", ErrInvalidTransition, event, current)
+	}
+
+	t := TransitionEvent{Machine: m.config.Name, Event: event, From: current, To: transition.To, Args: args, At: time.Now()}
+
+	for _, guard := range transition.Guards {
+		if err := guard(ctx, entity, t); err != nil {
+			return &GuardError{Event: event, From: current, Err: err}
+		}
+	}
+
+	for _, hook := range m.config.OnExit[current] {
+		if err := hook(ctx, entity, t); err != nil {
+			return err
+		}
+	}
+
+	entity.SetState(transition.To)
+
+	for _, hook := range m.config.OnEnter[transition.To] {
+		if err := hook(ctx, entity, t); err != nil {
+			entity.SetState(current)
+			return err
+		}
+	}
+
+	for _, listener := range m.config.Listeners {
+		listener(ctx, entity, t)
+	}
+
+	return nil
+}
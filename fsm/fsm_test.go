@@ -0,0 +1,158 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type payment struct {
+	ID         int64
+	Status     State
+	Amount     float64
+	ApprovedAt time.Time `db:"approved_at"`
+}
+
+func (p *payment) State() State         { return p.Status }
+func (p *payment) SetState(state State) { p.Status = state }
+
+var errInsufficientFunds = errors.New("insufficient funds")
+
+func newPaymentMachine(t *testing.T, config Config) *Machine {
+	hasFunds := func(ctx context.Context, entity Stateful, t TransitionEvent) error {
+		if entity.(*payment).Amount > 100 {
+			return errInsufficientFunds
+		}
+		return nil
+	}
+
+	config.Name = "payment"
+	config.Transitions = []Transition{
+		{Event: "approve", From: []State{"pending"}, To: "approved", Guards: []Guard{hasFunds}},
+		{Event: "reject", From: []State{"pending"}, To: "rejected"},
+		{Event: "refund", From: []State{"approved"}, To: "refunded"},
+		{Event: "cancel", From: []State{"pending", "approved"}, To: "cancelled"},
+	}
+
+	m, err := New(config)
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err.Error())
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	assert.Equal(t, ErrNoTransitions, err)
+
+	_, err = New(Config{Transitions: []Transition{
+		{Event: "approve", From: []State{"pending"}, To: "approved"},
+		{Event: "approve", From: []State{"pending"}, To: "rejected"},
+	}})
+	assert.True(t, errors.Is(err, ErrDuplicatedEvent))
+
+	m := newPaymentMachine(t, Config{})
+	assert.Equal(t, []State{"approved", "cancelled", "pending", "refunded", "rejected"}, m.States())
+	assert.Equal(t, []Event{"approve", "cancel", "reject"}, m.AvailableEvents("pending"))
+	assert.True(t, m.IsFinal("refunded"))
+}
+
+func TestFire(t *testing.T) {
+	var transitions []TransitionEvent
+	m := newPaymentMachine(t, Config{
+		Listeners: []Listener{func(ctx context.Context, entity Stateful, t TransitionEvent) {
+			transitions = append(transitions, t)
+		}},
+	})
+
+	p := &payment{Status: "pending", Amount: 500}
+	ctx := context.Background()
+
+	err := m.Fire(ctx, p, "approve")
+	assert.True(t, errors.Is(err, errInsufficientFunds))
+	assert.Equal(t, State("pending"), p.Status)
+
+	p.Amount = 50
+	assert.Nil(t, m.Fire(ctx, p, "approve", "auth-code-123"))
+	assert.Equal(t, State("approved"), p.Status)
+	assert.True(t, m.Can(p, "refund"))
+	assert.False(t, m.Can(p, "reject"))
+
+	assert.True(t, errors.Is(m.Fire(ctx, p, "reject"), ErrInvalidTransition))
+	assert.True(t, errors.Is(m.Fire(ctx, p, "chargeback"), ErrUnknownEvent))
+
+	if assert.Len(t, transitions, 1) {
+		assert.Equal(t, "payment", transitions[0].Machine)
+		assert.Equal(t, State("pending"), transitions[0].From)
+		assert.Equal(t, State("approved"), transitions[0].To)
+		assert.Equal(t, []interface{}{"auth-code-123"}, transitions[0].Args)
+	}
+}
+
+func TestFireHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context, entity Stateful, t TransitionEvent) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	m := newPaymentMachine(t, Config{
+		OnExit:  map[State][]Hook{"pending": {hook("exit pending", nil)}},
+		OnEnter: map[State][]Hook{"approved": {hook("enter approved", nil)}, "rejected": {hook("enter rejected", errors.New("notification failed"))}},
+	})
+
+	p := &payment{Status: "pending"}
+	assert.Nil(t, m.Fire(context.Background(), p, "approve"))
+	assert.Equal(t, []string{"exit pending", "enter approved"}, calls)
+
+	// failed entry hooks restore the previous state
+	p.Status = "pending"
+	assert.NotNil(t, m.Fire(context.Background(), p, "reject"))
+	assert.Equal(t, State("pending"), p.Status)
+}
+
+func TestStatusUpdater(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err.Error())
+	}
+	defer db.Close()
+
+	if _, err = db.Exec("CREATE TABLE payments (id INTEGER PRIMARY KEY, status VARCHAR(20), approved_at DATETIME)"); err != nil {
+		t.Fatalf("Error creating table: %s", err.Error())
+	}
+	db.Exec("INSERT INTO payments (id, status) VALUES (1, 'pending')")
+
+	ctx := context.Background()
+	updater := StatusUpdater{Table: "payments", IDColumn: "id", StatusColumn: "status"}
+
+	p := &payment{ID: 1, Status: "pending", ApprovedAt: time.Now()}
+	m := newPaymentMachine(t, Config{
+		Listeners: []Listener{func(ctx context.Context, entity Stateful, tr TransitionEvent) {
+			assert.Nil(t, updater.Update(ctx, db, tr, p.ID, p, "ApprovedAt"))
+		}},
+	})
+	assert.Nil(t, m.Fire(ctx, p, "approve"))
+
+	var status State
+	db.QueryRow("SELECT status FROM payments WHERE id = 1").Scan(&status)
+	assert.Equal(t, State("approved"), status)
+
+	// the row is no longer pending
+	stale := TransitionEvent{Event: "reject", From: "pending", To: "rejected"}
+	assert.Equal(t, ErrStaleState, updater.Update(ctx, db, stale, 1, nil))
+
+	// invalid entities or fields are errors
+	var nilPayment *payment
+	approve := TransitionEvent{Event: "approve", From: "pending", To: "approved"}
+	assert.Equal(t, ErrNilEntity, updater.Update(ctx, db, approve, 1, nilPayment, "ApprovedAt"))
+	assert.Equal(t, ErrNilEntity, updater.Update(ctx, db, approve, 1, nil, "ApprovedAt"))
+	assert.NotNil(t, updater.Update(ctx, db, approve, 1, p, "Unknown"))
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/astropay/go-tools/database"
+)
+
+// Persistence errors
+var (
+	ErrStaleState = errors.New("entity state was changed by someone else")
+	ErrNilEntity  = errors.New("fields can't be updated from a nil entity")
+)
+
+// Execer is implemented by *sql.DB, *sql.Tx, *sqlx.DB and *sqlx.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// StatusUpdater persists transitions to a status column, using the previous state as an
+// optimistic lock: the update fails with `ErrStaleState` if the row is no longer in that state
+type StatusUpdater struct {
+	Table        string
+	IDColumn     string
+	StatusColumn string
+}
+
+// Update sets the new status of the row with the indicated id. Additional fields of `obj` (struct
+// field names, mapped to columns by their `db` tag) can be updated in the same statement, with
+// the same value conversions as the database package (nil pointers, json fields), ie:
+//
+//	updater.Update(ctx, tx, t, payment.ID, payment, "ApprovedAt", "AuthCode")
+func (u StatusUpdater) Update(ctx context.Context, exec Execer, t TransitionEvent, id interface{}, obj interface{}, fields ...string) error {
+	set := fmt.Sprintf("SET %s=?", u.StatusColumn)
+	args := []interface{}{t.To}
+
+	if len(fields) > 0 {
+		if objVal := reflect.ValueOf(obj); !objVal.IsValid() || objVal.Kind() == reflect.Ptr && objVal.IsNil() {
+			return ErrNilEntity
+		}
+
+		fieldsSet, fieldsArgs, err := database.BuildParametrizedUpdateSet(obj, fields)
+		if err != nil {
+			return err
+		}
+		set += "," + strings.TrimPrefix(fieldsSet, "SET ")
+		args = append(args, fieldsArgs...)
+	}
+
+	query := fmt.Sprintf("UPDATE %s %s WHERE %s=? AND %s=?", u.Table, set, u.IDColumn, u.StatusColumn)
+	args = append(args, id, t.From)
+
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrStaleState
+	}
+
+	return nil
+}
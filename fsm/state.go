@@ -0,0 +1,43 @@
+package fsm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+)
+
+// State is a state of the machine; it can be read from and written to a status column
+type State string
+
+// String returns the state as string
+func (s State) String() string {
+	return string(s)
+}
+
+// Value implements driver.Valuer
+func (s State) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Scan implements sql.Scanner
+func (s *State) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = State(v)
+	case []byte:
+		*s = State(v)
+	case nil:
+		*s = ""
+	default:
+		return fmt.Errorf("cannot scan %T into fsm.State", value)
+	}
+	return nil
+}
+
+func sortStates(states []State) {
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+}
+
+func sortEvents(events []Event) {
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+}
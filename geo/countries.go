@@ -0,0 +1,72 @@
+package geo
+
+import "strings"
+
+// Country is an ISO-3166-1 country
+type Country struct {
+	Alpha2  string `json:"alpha2"`
+	Alpha3  string `json:"alpha3"`
+	Numeric string `json:"numeric"`
+	Name    string `json:"name"`
+}
+
+// indexes over the countries list
+var (
+	byAlpha2  = make(map[string]Country, len(countries))
+	byAlpha3  = make(map[string]Country, len(countries))
+	byNumeric = make(map[string]Country, len(countries))
+)
+
+func init() {
+	for _, c := range countries {
+		byAlpha2[c.Alpha2] = c
+		byAlpha3[c.Alpha3] = c
+		byNumeric[c.Numeric] = c
+	}
+}
+
+// Countries returns all the ISO-3166-1 countries, sorted by alpha-2 code
+func Countries() []Country {
+	result := make([]Country, len(countries))
+	copy(result, countries)
+	return result
+}
+
+// CountryByCode returns the country by its alpha-2, alpha-3 or numeric code (case insensitive)
+func CountryByCode(code string) (country Country, found bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	switch len(code) {
+	case 2:
+		country, found = byAlpha2[code]
+	case 3:
+		if country, found = byAlpha3[code]; !found {
+			country, found = byNumeric[code]
+		}
+	}
+	return
+}
+
+// IsValidCountryCode returns true if code is an ISO-3166-1 alpha-2 code (ie: "UY")
+func IsValidCountryCode(code string) bool {
+	_, found := byAlpha2[strings.ToUpper(code)]
+	return found
+}
+
+// Alpha2ToAlpha3 converts an alpha-2 code to alpha-3 (ie: "UY" -> "URY")
+func Alpha2ToAlpha3(code string) (alpha3 string, found bool) {
+	country, found := byAlpha2[strings.ToUpper(code)]
+	return country.Alpha3, found
+}
+
+// Alpha3ToAlpha2 converts an alpha-3 code to alpha-2 (ie: "URY" -> "UY")
+func Alpha3ToAlpha2(code string) (alpha2 string, found bool) {
+	country, found := byAlpha3[strings.ToUpper(code)]
+	return country.Alpha2, found
+}
+
+// CountryName returns the english name of the country, or an empty string for unknown codes
+func CountryName(code string) string {
+	country, _ := CountryByCode(code)
+	return country.Name
+}
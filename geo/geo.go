@@ -0,0 +1,260 @@
+// Package geo resolves the location of IP addresses (from a MaxMind database or from IP ranges
+// loaded in memory), provides ISO-3166 country code utilities and a middleware that annotates
+// the requests with the caller's country, ie: to apply licensing and fraud rules per jurisdiction.
+//
+//	db, err := geo.OpenMaxMind("/data/GeoIP2-City.mmdb")
+//	proxies, err := geo.ParseNetworks("10.0.0.0/8")
+//	handler = geo.Middleware(geo.MiddlewareConfig{Locator: db, TrustedProxies: proxies, Blocked: []string{"KP", "IR"}})(handler)
+//
+//	location, _ := geo.FromContext(r.Context())
+package geo
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Geo errors
+var (
+	ErrInvalidIP = errors.New("invalid ip address")
+	ErrNotFound  = errors.New("ip address not found in the database")
+)
+
+// Location is the resolved location of an IP address
+type Location struct {
+	CountryCode string  `json:"country_code"` // ISO-3166-1 alpha-2
+	Region      string  `json:"region,omitempty"`
+	City        string  `json:"city,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+}
+
+// Locator resolves the location of IP addresses
+type Locator interface {
+	Lookup(ip net.IP) (Location, error)
+}
+
+// LookupString parses the IP address and resolves its location
+func LookupString(locator Locator, ip string) (Location, error) {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return Location{}, ErrInvalidIP
+	}
+	return locator.Lookup(parsed)
+}
+
+// IsPrivate returns true for loopback, link local and private network addresses, which
+// can't be located
+func IsPrivate(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var privateNetworks = parseNetworks("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8",
+	"169.254.0.0/16", "100.64.0.0/10", "::1/128", "fc00::/7", "fe80::/10")
+
+func parseNetworks(cidrs ...string) (networks []*net.IPNet) {
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return
+}
+
+// ipRange is a range of addresses with the same location
+type ipRange struct {
+	first    net.IP
+	last     net.IP
+	location Location
+}
+
+// RangeDB is an in-memory locator built from network ranges, ie: to load a CSV database or to
+// mock the locations in tests
+type RangeDB struct {
+	// networks as added, and flattened into non-overlapping ranges where the nested networks
+	// take precedence, so a lookup is a single binary search
+	networks []ipRange
+	ranges   []ipRange
+}
+
+// NewRangeDB creates an empty database
+func NewRangeDB() *RangeDB {
+	return new(RangeDB)
+}
+
+// Add maps the network (CIDR notation, ie: 200.40.0.0/16) to the location
+func (db *RangeDB) Add(cidr string, location Location) error {
+	if err := db.add(cidr, location); err != nil {
+		return err
+	}
+
+	db.sort()
+	return nil
+}
+
+func (db *RangeDB) add(cidr string, location Location) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	first := network.IP
+	last := make(net.IP, len(first))
+	for i := range first {
+		last[i] = first[i] | ^network.Mask[i]
+	}
+
+	db.networks = append(db.networks, ipRange{first: normalize(first), last: normalize(last), location: location})
+	return nil
+}
+
+// sorts the networks and flattens them into the lookup ranges
+func (db *RangeDB) sort() {
+	// by first address; nested networks after the ones containing them
+	sort.Slice(db.networks, func(i, j int) bool {
+		if c := bytes.Compare(db.networks[i].first, db.networks[j].first); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(db.networks[i].last, db.networks[j].last) > 0
+	})
+
+	db.ranges = flatten(db.networks)
+}
+
+// splits the sorted networks into non-overlapping ranges. Networks are either disjoint or nested,
+// so the ones containing the current network are kept in a stack, and each range takes the
+// location of the innermost network.
+func flatten(networks []ipRange) (ranges []ipRange) {
+	var stack []ipRange
+	var next net.IP // first address not covered yet; nil after the last address
+	emit := func(first, last net.IP, location Location) {
+		if next != nil && bytes.Compare(first, last) <= 0 {
+			ranges = append(ranges, ipRange{first: first, last: last, location: location})
+			next = increment(last)
+		}
+	}
+	pop := func() {
+		top := stack[len(stack)-1]
+		emit(next, top.last, top.location)
+		stack = stack[:len(stack)-1]
+	}
+
+	for _, network := range networks {
+		for len(stack) > 0 && bytes.Compare(stack[len(stack)-1].last, network.first) < 0 {
+			pop()
+		}
+
+		if len(stack) > 0 && bytes.Compare(next, network.first) < 0 {
+			top := stack[len(stack)-1]
+			emit(next, decrement(network.first), top.location)
+		}
+
+		next = network.first
+		stack = append(stack, network)
+	}
+
+	for len(stack) > 0 {
+		pop()
+	}
+
+	return
+}
+
+// returns the next address, or nil after the last one
+func increment(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i]++; next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
+
+// returns the previous address; ip must not be the first one
+func decrement(ip net.IP) net.IP {
+	prev := make(net.IP, len(ip))
+	copy(prev, ip)
+	for i := len(prev) - 1; i >= 0; i-- {
+		if prev[i]--; prev[i] != 0xff {
+			break
+		}
+	}
+	return prev
+}
+
+// LoadCSV adds the networks of a CSV with the columns network, country_code, region, city,
+// latitude and longitude (only the first two are required); a header row is skipped
+func (db *RangeDB) LoadCSV(r io.Reader) error {
+	defer db.sort()
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if line == 1 && record[0] == "network" {
+			continue
+		}
+
+		if len(record) < 2 {
+			return fmt.Errorf("line %d: network and country_code are required", line)
+		}
+
+		location := Location{CountryCode: strings.ToUpper(record[1])}
+		if len(record) > 2 {
+			location.Region = record[2]
+		}
+		if len(record) > 3 {
+			location.City = record[3]
+		}
+		if len(record) > 5 {
+			location.Latitude, _ = strconv.ParseFloat(record[4], 64)
+			location.Longitude, _ = strconv.ParseFloat(record[5], 64)
+		}
+
+		if err = db.add(record[0], location); err != nil {
+			return fmt.Errorf("line %d: %s", line, err.Error())
+		}
+	}
+}
+
+// Lookup returns the location of the most specific network containing the address
+func (db *RangeDB) Lookup(ip net.IP) (Location, error) {
+	if ip == nil {
+		return Location{}, ErrInvalidIP
+	}
+	ip = normalize(ip)
+
+	// the last range starting at or before the address
+	n := sort.Search(len(db.ranges), func(i int) bool { return bytes.Compare(db.ranges[i].first, ip) > 0 })
+	if n > 0 && bytes.Compare(ip, db.ranges[n-1].last) <= 0 {
+		return db.ranges[n-1].location, nil
+	}
+
+	return Location{}, ErrNotFound
+}
+
+// returns the 16 bytes form of the address
+func normalize(ip net.IP) net.IP {
+	return ip.To16()
+}
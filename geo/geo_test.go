@@ -0,0 +1,184 @@
+package geo
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountries(t *testing.T) {
+	assert.Len(t, Countries(), 249)
+	assert.True(t, IsValidCountryCode("uy"))
+	assert.False(t, IsValidCountryCode("XX"))
+
+	country, found := CountryByCode("URY")
+	assert.True(t, found)
+	assert.Equal(t, Country{Alpha2: "UY", Alpha3: "URY", Numeric: "858", Name: "Uruguay"}, country)
+
+	country, _ = CountryByCode("076")
+	assert.Equal(t, "BR", country.Alpha2)
+
+	alpha3, _ := Alpha2ToAlpha3("ar")
+	assert.Equal(t, "ARG", alpha3)
+
+	alpha2, _ := Alpha3ToAlpha2("MEX")
+	assert.Equal(t, "MX", alpha2)
+
+	assert.Equal(t, "Chile", CountryName("CL"))
+	assert.Equal(t, "", CountryName("ZZZ"))
+}
+
+func newTestDB(t *testing.T) *RangeDB {
+	db := NewRangeDB()
+	err := db.LoadCSV(strings.NewReader(`network,country_code,region,city,latitude,longitude
+200.40.0.0/16,uy,MO,Montevideo,-34.9,-56.2
+200.40.10.0/24,UY,CA,Canelones,-34.5,-56.3
+177.0.0.0/8,BR
+2800:a0::/32,UY`))
+	if err != nil {
+		t.Fatalf("LoadCSV() returned an error: %s", err.Error())
+	}
+	return db
+}
+
+func TestRangeDB(t *testing.T) {
+	db := newTestDB(t)
+
+	location, err := LookupString(db, "200.40.1.1")
+	assert.Nil(t, err)
+	assert.Equal(t, Location{CountryCode: "UY", Region: "MO", City: "Montevideo", Latitude: -34.9, Longitude: -56.2}, location)
+
+	// the most specific network wins
+	location, _ = LookupString(db, "200.40.10.200")
+	assert.Equal(t, "Canelones", location.City)
+
+	location, _ = LookupString(db, "177.200.3.4")
+	assert.Equal(t, "BR", location.CountryCode)
+
+	location, _ = LookupString(db, "2800:a0::1")
+	assert.Equal(t, "UY", location.CountryCode)
+
+	_, err = LookupString(db, "8.8.8.8")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = LookupString(db, "not an ip")
+	assert.Equal(t, ErrInvalidIP, err)
+
+	assert.NotNil(t, NewRangeDB().LoadCSV(strings.NewReader("200.40.0.0/33,UY")))
+}
+
+func TestRangeDBNested(t *testing.T) {
+	db := NewRangeDB()
+	for cidr, country := range map[string]string{
+		"10.0.0.0/8": "AR", "10.1.0.0/16": "BR", "10.1.2.0/24": "CL", "10.1.3.0/24": "UY", "10.2.0.0/16": "PE", "::/0": "ZZ",
+	} {
+		assert.Nil(t, db.Add(cidr, Location{CountryCode: country}))
+	}
+
+	for ip, country := range map[string]string{
+		"10.0.0.0": "AR", "10.1.0.0": "BR", "10.1.1.255": "BR", "10.1.2.0": "CL", "10.1.2.255": "CL", "10.1.3.128": "UY",
+		"10.1.4.0": "BR", "10.1.255.255": "BR", "10.2.0.1": "PE", "10.3.0.0": "AR", "10.255.255.255": "AR",
+		"11.0.0.0": "ZZ", "9.255.255.255": "ZZ", "::": "ZZ", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff": "ZZ",
+	} {
+		location, err := LookupString(db, ip)
+		assert.Nil(t, err)
+		assert.Equal(t, country, location.CountryCode, ip)
+	}
+
+	// the ranges don't overlap
+	for i := 1; i < len(db.ranges); i++ {
+		assert.True(t, bytes.Compare(db.ranges[i-1].last, db.ranges[i].first) < 0)
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	assert.True(t, IsPrivate(net.ParseIP("192.168.1.10")))
+	assert.True(t, IsPrivate(net.ParseIP("::1")))
+	assert.False(t, IsPrivate(net.ParseIP("200.40.1.1")))
+}
+
+func TestMiddleware(t *testing.T) {
+	var country string
+	proxies, err := ParseNetworks("10.0.0.0/8")
+	assert.Nil(t, err)
+
+	handler := Middleware(MiddlewareConfig{Locator: newTestDB(t), TrustedProxies: proxies, CountryHeader: "CF-IPCountry", Blocked: []string{"br"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			country = CountryFromContext(r.Context())
+		}),
+	)
+
+	request := func(remoteAddr string, headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		country = ""
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("200.40.1.1:5000", nil))
+	assert.Equal(t, "UY", country)
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000", map[string]string{"X-Forwarded-For": "200.40.10.1, 10.0.0.2"}))
+	assert.Equal(t, "UY", country)
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000", map[string]string{"CF-IPCountry": "AR"}))
+	assert.Equal(t, "AR", country)
+
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request("177.1.1.1:5000", nil))
+
+	// the headers sent by the caller are ignored
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request("177.1.1.1:5000", map[string]string{"X-Forwarded-For": "200.40.10.1"}))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request("177.1.1.1:5000", map[string]string{"CF-IPCountry": "UY"}))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, request("10.0.0.1:5000", map[string]string{"X-Forwarded-For": "200.40.10.1, 177.1.1.1"}))
+
+	// only allowed countries
+	allowed := Middleware(MiddlewareConfig{Locator: newTestDB(t), Allowed: []string{"UY"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "8.8.8.8:5000"
+	allowed.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, rec.Code)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseNetworks("10.0.0.0/8", "172.16.0.5", "2001:db8::/32")
+	assert.Nil(t, err)
+
+	request := func(remoteAddr string, forwarded ...string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, f := range forwarded {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		return ClientIP(req, proxies).String()
+	}
+
+	assert.Equal(t, "200.40.1.1", request("200.40.1.1:5000", "8.8.8.8"))
+	assert.Equal(t, "200.40.1.1", request("10.0.0.1:5000", "8.8.8.8, 200.40.1.1"))
+	assert.Equal(t, "200.40.1.1", request("10.0.0.1:5000", "8.8.8.8, 200.40.1.1, 172.16.0.5", "10.0.0.2"))
+	assert.Equal(t, "10.0.0.1", request("10.0.0.1:5000"))
+	assert.Equal(t, "10.0.0.2", request("10.0.0.1:5000", "invalid, 10.0.0.2"))
+	assert.Equal(t, "2001:db8::1", request("[2001:db8::1]:5000"))
+	assert.Equal(t, "200.40.1.1", request("[2001:db8::1]:5000", "200.40.1.1"))
+
+	_, err = ParseNetworks("10.0.0.0/33")
+	assert.NotNil(t, err)
+	_, err = ParseNetworks("invalid")
+	assert.NotNil(t, err)
+}
+
+func TestMaxMindInvalidDatabase(t *testing.T) {
+	_, err := NewMaxMindFromBytes([]byte("not a database"))
+	assert.NotNil(t, err)
+}
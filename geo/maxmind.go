@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MaxMindDB locates addresses using a MaxMind GeoIP2/GeoLite2 Country or City database (mmdb)
+type MaxMindDB struct {
+	reader *maxminddb.Reader
+}
+
+// fields read from the GeoIP2 records
+type maxMindRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// OpenMaxMind opens the database file; the file is memory mapped, so the lookups don't read it
+func OpenMaxMind(path string) (db *MaxMindDB, err error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return
+	}
+	return &MaxMindDB{reader: reader}, nil
+}
+
+// NewMaxMindFromBytes creates a locator from the database contents
+func NewMaxMindFromBytes(data []byte) (db *MaxMindDB, err error) {
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return
+	}
+	return &MaxMindDB{reader: reader}, nil
+}
+
+// Lookup returns the location of the address
+func (db *MaxMindDB) Lookup(ip net.IP) (location Location, err error) {
+	if ip == nil {
+		return location, ErrInvalidIP
+	}
+
+	var record maxMindRecord
+	_, found, err := db.reader.LookupNetwork(ip, &record)
+	if err != nil {
+		return
+	}
+
+	if !found || record.Country.ISOCode == "" {
+		return location, ErrNotFound
+	}
+
+	location = Location{
+		CountryCode: record.Country.ISOCode,
+		City:        record.City.Names["en"],
+		Latitude:    record.Location.Latitude,
+		Longitude:   record.Location.Longitude,
+	}
+
+	if len(record.Subdivisions) > 0 {
+		location.Region = record.Subdivisions[0].ISOCode
+	}
+
+	return
+}
+
+// Close releases the database
+func (db *MaxMindDB) Close() error {
+	return db.reader.Close()
+}
@@ -0,0 +1,163 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// MiddlewareConfig holds the middleware configuration
+type MiddlewareConfig struct {
+	Locator Locator
+
+	// TrustedProxies are the networks of the proxies and load balancers in front of the service
+	// (see ParseNetworks). The X-Forwarded-For and CountryHeader headers are only used on requests
+	// coming from them, since any other caller can set them (see ClientIP).
+	TrustedProxies []*net.IPNet
+
+	// CountryHeader is a header set by a CDN with the caller's country (ie: CF-IPCountry); when
+	// present in a request from a trusted proxy, it's used instead of the lookup
+	CountryHeader string
+
+	// Allowed and Blocked restrict the countries that can access the handler; requests from
+	// other countries are rejected with 451 (Unavailable For Legal Reasons). When Allowed is
+	// set, requests whose country can't be resolved are rejected too.
+	Allowed []string
+	Blocked []string
+}
+
+// Middleware resolves the caller's location and stores it in the request context
+func Middleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	allowed, blocked := countrySet(config.Allowed), countrySet(config.Blocked)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			location := resolve(config, r)
+
+			if (len(allowed) > 0 && !allowed[location.CountryCode]) || blocked[location.CountryCode] {
+				http.Error(w, http.StatusText(http.StatusUnavailableForLegalReasons), http.StatusUnavailableForLegalReasons)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), location)))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying the location
+func NewContext(ctx context.Context, location Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, location)
+}
+
+// FromContext returns the location stored by the middleware
+func FromContext(ctx context.Context) (location Location, found bool) {
+	location, found = ctx.Value(contextKey{}).(Location)
+	return
+}
+
+// CountryFromContext returns the caller's country code, or an empty string if unknown
+func CountryFromContext(ctx context.Context) string {
+	location, _ := FromContext(ctx)
+	return location.CountryCode
+}
+
+// ClientIP returns the caller's address. When the request comes from one of the trusted proxies,
+// the X-Forwarded-For entries are walked from right to left (each proxy appends the address it
+// received the request from) and the first one that isn't a trusted proxy is returned; entries
+// on its left are ignored, since they're set by the caller.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	ip := remoteIP(r)
+	if ip == nil || !contains(trustedProxies, ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !contains(trustedProxies, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// ParseNetworks parses a list of CIDRs (ie: "10.0.0.0/8") or single addresses
+func ParseNetworks(values ...string) (networks []*net.IPNet, err error) {
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address '%s'", value)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		var network *net.IPNet
+		if _, network, err = net.ParseCIDR(value); err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return
+}
+
+func resolve(config MiddlewareConfig, r *http.Request) (location Location) {
+	if config.CountryHeader != "" && contains(config.TrustedProxies, remoteIP(r)) {
+		if country := strings.ToUpper(r.Header.Get(config.CountryHeader)); IsValidCountryCode(country) {
+			return Location{CountryCode: country}
+		}
+	}
+
+	ip := ClientIP(r, config.TrustedProxies)
+	if ip == nil || IsPrivate(ip) || config.Locator == nil {
+		return
+	}
+
+	location, _ = config.Locator.Lookup(ip)
+	return
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func countrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[strings.ToUpper(code)] = true
+	}
+	return set
+}
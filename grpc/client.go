@@ -0,0 +1,269 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client errors
+var (
+	ErrPoolClosed = errors.New("connection pool is closed")
+)
+
+// RetryPolicy is the retry configuration applied by the gRPC client (through the service config)
+type RetryPolicy struct {
+	// MaxAttempts includes the original call (2 to 5)
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+
+	// RetryableCodes defaults to Unavailable
+	RetryableCodes []codes.Code
+}
+
+// ClientConfig holds the configuration of a client connection pool
+type ClientConfig struct {
+	// Target is the server address (ie: "payments:9090" or "dns:///payments:9090")
+	Target string
+
+	// PoolSize is the number of connections; calls are distributed using round robin (default 1)
+	PoolSize int
+
+	// Credentials used by the connections; when nil, connections are insecure (plain text)
+	Credentials credentials.TransportCredentials
+
+	// DialTimeout is the maximum time to wait for the connections to be established;
+	// zero means connections are established in background
+	DialTimeout time.Duration
+
+	// Retry enables automatic retries (optional)
+	Retry *RetryPolicy
+
+	// Interceptors, executed in the indicated order
+	UnaryInterceptors  []grpclib.UnaryClientInterceptor
+	StreamInterceptors []grpclib.StreamClientInterceptor
+
+	// Options are passed as they are to grpc.Dial
+	Options []grpclib.DialOption
+}
+
+// Pool is a set of connections to the same target; use `Conn()` to get the connection for each call
+type Pool struct {
+	conns  []*grpclib.ClientConn
+	next   uint64
+	closed int32
+}
+
+// NewPool dials all the connections of the pool
+func NewPool(config ClientConfig) (p *Pool, err error) {
+	if config.PoolSize <= 0 {
+		config.PoolSize = 1
+	}
+
+	var options []grpclib.DialOption
+	if options, err = dialOptions(config); err != nil {
+		return
+	}
+
+	p = &Pool{conns: make([]*grpclib.ClientConn, 0, config.PoolSize)}
+
+	for i := 0; i < config.PoolSize; i++ {
+		var conn *grpclib.ClientConn
+		if conn, err = dial(config, options); err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, conn)
+	}
+
+	return
+}
+
+// Conn returns the next connection of the pool
+func (p *Pool) Conn() *grpclib.ClientConn {
+	n := atomic.AddUint64(&p.next, 1)
+	return p.conns[n%uint64(len(p.conns))]
+}
+
+// Close closes all the connections
+func (p *Pool) Close() (err error) {
+	if !atomic.CompareAndSwapInt32(&p.closed, 0, 1) {
+		return ErrPoolClosed
+	}
+
+	for _, c := range p.conns {
+		if closeErr := c.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
+
+// ClientFactory keeps one pool per target, so all the clients of a service share connections
+type ClientFactory struct {
+	defaults ClientConfig
+
+	mutex sync.Mutex
+	pools map[string]*Pool
+}
+
+// NewClientFactory creates a factory; `defaults` is used for every target (Target is ignored)
+func NewClientFactory(defaults ClientConfig) *ClientFactory {
+	return &ClientFactory{defaults: defaults, pools: make(map[string]*Pool)}
+}
+
+// Conn returns a connection to the target, creating its pool if needed
+func (f *ClientFactory) Conn(target string) (*grpclib.ClientConn, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	pool, exists := f.pools[target]
+	if !exists {
+		config := f.defaults
+		config.Target = target
+
+		var err error
+		if pool, err = NewPool(config); err != nil {
+			return nil, err
+		}
+		f.pools[target] = pool
+	}
+
+	return pool.Conn(), nil
+}
+
+// Close closes all the pools
+func (f *ClientFactory) Close() (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for target, pool := range f.pools {
+		if closeErr := pool.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(f.pools, target)
+	}
+	return
+}
+
+// builds the dial options from the configuration
+func dialOptions(config ClientConfig) (options []grpclib.DialOption, err error) {
+	creds := config.Credentials
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	options = append(options, grpclib.WithTransportCredentials(creds))
+
+	if config.Retry != nil {
+		var serviceConfig string
+		if serviceConfig, err = retryServiceConfig(*config.Retry); err != nil {
+			return
+		}
+		options = append(options, grpclib.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	if len(config.UnaryInterceptors) > 0 {
+		options = append(options, grpclib.WithChainUnaryInterceptor(config.UnaryInterceptors...))
+	}
+	if len(config.StreamInterceptors) > 0 {
+		options = append(options, grpclib.WithChainStreamInterceptor(config.StreamInterceptors...))
+	}
+
+	if config.DialTimeout > 0 {
+		options = append(options, grpclib.WithBlock())
+	}
+
+	options = append(options, config.Options...)
+	return
+}
+
+func dial(config ClientConfig, options []grpclib.DialOption) (*grpclib.ClientConn, error) {
+	ctx := context.Background()
+	if config.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.DialTimeout)
+		defer cancel()
+	}
+
+	return grpclib.DialContext(ctx, config.Target, options...)
+}
+
+// builds the service config JSON with the retry policy for all the methods
+func retryServiceConfig(policy RetryPolicy) (string, error) {
+	if policy.MaxAttempts < 2 || policy.MaxAttempts > 5 {
+		return "", fmt.Errorf("invalid retry max attempts %d (must be between 2 and 5)", policy.MaxAttempts)
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = time.Second
+	}
+	if policy.BackoffMultiplier <= 0 {
+		policy.BackoffMultiplier = 2
+	}
+	if len(policy.RetryableCodes) == 0 {
+		policy.RetryableCodes = []codes.Code{codes.Unavailable}
+	}
+
+	retryableCodes := make([]string, len(policy.RetryableCodes))
+	for i, c := range policy.RetryableCodes {
+		retryableCodes[i] = codeName(c)
+	}
+
+	serviceConfig := map[string]interface{}{
+		"methodConfig": []interface{}{
+			map[string]interface{}{
+				"name": []interface{}{map[string]interface{}{}},
+				"retryPolicy": map[string]interface{}{
+					"maxAttempts":          policy.MaxAttempts,
+					"initialBackoff":       fmt.Sprintf("%.3fs", policy.InitialBackoff.Seconds()),
+					"maxBackoff":           fmt.Sprintf("%.3fs", policy.MaxBackoff.Seconds()),
+					"backoffMultiplier":    policy.BackoffMultiplier,
+					"retryableStatusCodes": retryableCodes,
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(serviceConfig)
+	return string(data), err
+}
+
+// returns the code name as used in service configs (ie: "UNAVAILABLE")
+func codeName(c codes.Code) string {
+	names := map[codes.Code]string{
+		codes.Canceled:           "CANCELLED",
+		codes.Unknown:            "UNKNOWN",
+		codes.InvalidArgument:    "INVALID_ARGUMENT",
+		codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+		codes.NotFound:           "NOT_FOUND",
+		codes.AlreadyExists:      "ALREADY_EXISTS",
+		codes.PermissionDenied:   "PERMISSION_DENIED",
+		codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+		codes.FailedPrecondition: "FAILED_PRECONDITION",
+		codes.Aborted:            "ABORTED",
+		codes.OutOfRange:         "OUT_OF_RANGE",
+		codes.Unimplemented:      "UNIMPLEMENTED",
+		codes.Internal:           "INTERNAL",
+		codes.Unavailable:        "UNAVAILABLE",
+		codes.DataLoss:           "DATA_LOSS",
+		codes.Unauthenticated:    "UNAUTHENTICATED",
+	}
+
+	if name, exists := names[c]; exists {
+		return name
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astropay/go-tools/auth"
+	"github.com/stretchr/testify/assert"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testRecorder struct {
+	mutex sync.Mutex
+	calls map[string]codes.Code
+}
+
+func (r *testRecorder) ObserveRPC(fullMethod string, code codes.Code, duration time.Duration) {
+	r.mutex.Lock()
+	r.calls[fullMethod] = code
+	r.mutex.Unlock()
+}
+
+// starts a server listening on an in-memory connection and returns a client pool connected to it
+func startTestServer(t *testing.T, config ServerConfig) (*Server, *Pool) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(config)
+
+	go server.ServeListener(listener)
+
+	pool, err := NewPool(ClientConfig{
+		Target:   "bufnet",
+		PoolSize: 2,
+		Options: []grpclib.DialOption{
+			grpclib.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %s", err.Error())
+	}
+
+	return server, pool
+}
+
+func TestServerHealth(t *testing.T) {
+	recorder := &testRecorder{calls: make(map[string]codes.Code)}
+	server, pool := startTestServer(t, ServerConfig{UnaryInterceptors: []grpclib.UnaryServerInterceptor{UnaryMetrics(recorder)}})
+	defer pool.Close()
+
+	client := healthpb.NewHealthClient(pool.Conn())
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() returned an error: %s", err.Error())
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	server.SetServingStatus("payments", false)
+	resp, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "payments"})
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	recorder.mutex.Lock()
+	assert.Equal(t, codes.OK, recorder.calls["/grpc.health.v1.Health/Check"])
+	recorder.mutex.Unlock()
+
+	server.Stop(time.Second)
+	assert.Equal(t, ErrPoolClosed, func() error { pool.Close(); return pool.Close() }())
+}
+
+func TestRecoveryAndAuth(t *testing.T) {
+	store := auth.KeyStoreFunc(func(ctx context.Context, hash string) (*auth.Principal, error) {
+		if hash == auth.HashAPIKey("valid") {
+			return &auth.Principal{ID: "service-a"}, nil
+		}
+		return nil, auth.ErrKeyNotFound
+	})
+
+	var principal *auth.Principal
+	panicking := func(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (interface{}, error) {
+		principal, _ = auth.FromContext(ctx)
+		if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("panic")) > 0 {
+			panic("boom")
+		}
+		return handler(ctx, req)
+	}
+
+	server, pool := startTestServer(t, ServerConfig{
+		UnaryInterceptors: []grpclib.UnaryServerInterceptor{
+			UnaryRecovery(nil),
+			UnaryAuth(APIKeyAuth(auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{Store: store}), "")),
+			panicking,
+		},
+	})
+	defer server.Stop(time.Second)
+	defer pool.Close()
+
+	client := healthpb.NewHealthClient(pool.Conn())
+
+	// no credentials
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// valid key
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "valid")
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "service-a", principal.ID)
+
+	// panic
+	ctx = metadata.AppendToOutgoingContext(ctx, "panic", "1")
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestRetryServiceConfig(t *testing.T) {
+	config, err := retryServiceConfig(RetryPolicy{MaxAttempts: 3, RetryableCodes: []codes.Code{codes.Unavailable, codes.Aborted}})
+	if err != nil {
+		t.Fatalf("retryServiceConfig() returned an error: %s", err.Error())
+	}
+
+	assert.True(t, strings.Contains(config, `"retryableStatusCodes":["UNAVAILABLE","ABORTED"]`), config)
+	assert.True(t, strings.Contains(config, `"initialBackoff":"0.100s"`), config)
+
+	_, err = retryServiceConfig(RetryPolicy{MaxAttempts: 10})
+	assert.NotNil(t, err)
+}
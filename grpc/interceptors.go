@@ -0,0 +1,187 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/astropay/go-tools/auth"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Logger is the minimal logging interface used by the interceptors (*log.Logger implements it)
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// MetricsRecorder receives the result of every call
+type MetricsRecorder interface {
+	ObserveRPC(fullMethod string, code codes.Code, duration time.Duration)
+}
+
+// RecoveryHandler converts a recovered panic into the error returned to the caller
+type RecoveryHandler func(ctx context.Context, p interface{}) error
+
+// AuthFunc authenticates the call; the returned context is used for the rest of the call
+// (ie: carrying the principal)
+type AuthFunc func(ctx context.Context, fullMethod string) (context.Context, error)
+
+// UnaryLogging logs every unary call with its status code and duration
+func UnaryLogging(logger Logger) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Printf("grpc: %s %s %s", info.FullMethod, status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamLogging logs every stream with its status code and duration
+func StreamLogging(logger Logger) grpclib.StreamServerInterceptor {
+	return func(srv interface{}, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, handler grpclib.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logger.Printf("grpc: %s %s %s", info.FullMethod, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+// UnaryMetrics reports every unary call to the recorder
+func UnaryMetrics(recorder MetricsRecorder) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		recorder.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamMetrics reports every stream to the recorder
+func StreamMetrics(recorder MetricsRecorder) grpclib.StreamServerInterceptor {
+	return func(srv interface{}, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, handler grpclib.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		recorder.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+// UnaryRecovery recovers from panics in the handlers; by default the panic is returned as an
+// Internal error (without details, the stack trace is not sent to the caller)
+func UnaryRecovery(handler RecoveryHandler) grpclib.UnaryServerInterceptor {
+	if handler == nil {
+		handler = defaultRecoveryHandler
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, next grpclib.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handler(ctx, p)
+			}
+		}()
+
+		return next(ctx, req)
+	}
+}
+
+// StreamRecovery recovers from panics in the stream handlers
+func StreamRecovery(handler RecoveryHandler) grpclib.StreamServerInterceptor {
+	if handler == nil {
+		handler = defaultRecoveryHandler
+	}
+
+	return func(srv interface{}, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, next grpclib.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handler(ss.Context(), p)
+			}
+		}()
+
+		return next(srv, ss)
+	}
+}
+
+// UnaryAuth authenticates unary calls; methods in `skipMethods` (full method names, ie: the
+// health check) are not authenticated
+func UnaryAuth(authFunc AuthFunc, skipMethods ...string) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (interface{}, error) {
+		if !skipAuth(info.FullMethod, skipMethods) {
+			var err error
+			if ctx, err = authFunc(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth authenticates streams; methods in `skipMethods` are not authenticated
+func StreamAuth(authFunc AuthFunc, skipMethods ...string) grpclib.StreamServerInterceptor {
+	return func(srv interface{}, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, handler grpclib.StreamHandler) error {
+		if skipAuth(info.FullMethod, skipMethods) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authFunc(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// APIKeyAuth returns an AuthFunc that validates the API key sent in the indicated metadata
+// key (default "x-api-key") and adds the principal to the context (see `auth.FromContext`)
+func APIKeyAuth(authenticator *auth.APIKeyAuthenticator, metadataKey string) AuthFunc {
+	if metadataKey == "" {
+		metadataKey = "x-api-key"
+	}
+	metadataKey = strings.ToLower(metadataKey)
+
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		var key string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(metadataKey); len(values) > 0 {
+				key = values[0]
+			}
+		}
+
+		p, err := authenticator.Authenticate(ctx, key)
+		if err != nil {
+			if err == auth.ErrMissingCredentials || err == auth.ErrInvalidCredentials {
+				return ctx, status.Error(codes.Unauthenticated, err.Error())
+			}
+			return ctx, status.Error(codes.Internal, err.Error())
+		}
+
+		return auth.NewContext(ctx, p), nil
+	}
+}
+
+// server stream with a replaced context
+type contextServerStream struct {
+	grpclib.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func defaultRecoveryHandler(ctx context.Context, p interface{}) error {
+	return status.Error(codes.Internal, "internal error")
+}
+
+func skipAuth(method string, skipMethods []string) bool {
+	for _, m := range skipMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Package grpc has helpers to build gRPC servers and clients with the same defaults in all
+// the services: health service, reflection, graceful stop, standard interceptors (logging,
+// metrics, panic recovery and authentication) and client connection pools with retries.
+//
+//	server := grpc.NewServer(grpc.ServerConfig{
+//		Address:           ":9090",
+//		EnableReflection:  true,
+//		UnaryInterceptors: []grpclib.UnaryServerInterceptor{grpc.UnaryRecovery(nil), grpc.UnaryLogging(logger)},
+//	})
+//	pb.RegisterPaymentsServer(server.GRPC(), paymentsService)
+//	go server.Serve()
+//	...
+//	server.Stop(10 * time.Second)
+package grpc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server errors
+var (
+	ErrServerNotStarted = errors.New("server not started")
+)
+
+// ServerConfig holds the configuration of a gRPC server
+type ServerConfig struct {
+	// Address to listen on (ie: ":9090")
+	Address string
+
+	// EnableReflection registers the reflection service (useful for grpcurl)
+	EnableReflection bool
+
+	// DisableHealth avoids registering the standard health service
+	DisableHealth bool
+
+	// Interceptors, executed in the indicated order
+	UnaryInterceptors  []grpclib.UnaryServerInterceptor
+	StreamInterceptors []grpclib.StreamServerInterceptor
+
+	// Options are passed as they are to grpc.NewServer
+	Options []grpclib.ServerOption
+}
+
+// Server wraps a grpc.Server adding the health service and graceful stop
+type Server struct {
+	config ServerConfig
+	server *grpclib.Server
+	health *health.Server
+
+	mutex    sync.Mutex
+	listener net.Listener
+}
+
+// NewServer creates a new server; services must be registered using `GRPC()` before calling `Serve()`
+func NewServer(config ServerConfig) *Server {
+	options := make([]grpclib.ServerOption, 0, len(config.Options)+2)
+	if len(config.UnaryInterceptors) > 0 {
+		options = append(options, grpclib.ChainUnaryInterceptor(config.UnaryInterceptors...))
+	}
+	if len(config.StreamInterceptors) > 0 {
+		options = append(options, grpclib.ChainStreamInterceptor(config.StreamInterceptors...))
+	}
+	options = append(options, config.Options...)
+
+	s := &Server{config: config, server: grpclib.NewServer(options...)}
+
+	if !config.DisableHealth {
+		s.health = health.NewServer()
+		healthpb.RegisterHealthServer(s.server, s.health)
+	}
+
+	if config.EnableReflection {
+		reflection.Register(s.server)
+	}
+
+	return s
+}
+
+// GRPC returns the underlying grpc.Server, used to register the services
+func (s *Server) GRPC() *grpclib.Server {
+	return s.server
+}
+
+// SetServingStatus updates the health status of a service; an empty name is the overall server status
+func (s *Server) SetServingStatus(service string, serving bool) {
+	if s.health == nil {
+		return
+	}
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus(service, status)
+}
+
+// Serve listens on the configured address and serves requests; it blocks until the server stops
+func (s *Server) Serve() error {
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return err
+	}
+
+	return s.ServeListener(listener)
+}
+
+// ServeListener serves requests on an existing listener; it blocks until the server stops
+func (s *Server) ServeListener(listener net.Listener) error {
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+
+	s.SetServingStatus("", true)
+
+	err := s.server.Serve(listener)
+	if err == grpclib.ErrServerStopped {
+		err = nil
+	}
+	return err
+}
+
+// Addr returns the address the server is listening on (useful when listening on port 0)
+func (s *Server) Addr() (net.Addr, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return nil, ErrServerNotStarted
+	}
+	return s.listener.Addr(), nil
+}
+
+// Stop marks the server as not serving and waits for the in-flight requests to finish; if
+// they don't finish within `timeout` the server is stopped anyway
+func (s *Server) Stop(timeout time.Duration) {
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.server.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,45 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Pinger is implemented by *sql.DB and *sqlx.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingChecker checks a database (or anything that can be pinged)
+func PingChecker(p Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return p.PingContext(ctx)
+	})
+}
+
+// HTTPChecker checks that a GET to the URL responds with a 2xx status code;
+// a nil client uses http.DefaultClient (the checker timeout is applied anyway)
+func HTTPChecker(client *http.Client, url string) Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,330 @@
+// Package healthcheck aggregates the health of the service dependencies (databases, Redis,
+// queues, downstream APIs) in a single status.
+//
+// Each component registers a named checker with its own timeout; checks run concurrently and
+// the result is cached for a short time, so health endpoints can be called often without
+// hammering the dependencies:
+//
+//	health := healthcheck.New(healthcheck.Config{CacheTTL: 2 * time.Second})
+//	health.Register("mysql", healthcheck.PingChecker(db))
+//	health.Register("redis", healthcheck.CheckerFunc(redisClient.HealthCheck(0)))
+//	health.Register("partner-api", healthcheck.HTTPChecker(nil, "https://partner/status"), healthcheck.NonCritical())
+//
+//	http.Handle("/health", health.Handler())
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Health status values
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Health check errors
+var (
+	ErrDuplicatedChecker = errors.New("a checker with the same name is already registered")
+)
+
+// Checker checks the health of a component; a nil error means the component is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as checkers
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Config holds the health check configuration
+type Config struct {
+	// CacheTTL is how long a result is reused (default 1 second); a negative value disables the cache
+	CacheTTL time.Duration
+
+	// DefaultTimeout is the timeout used by checkers registered without one (default 3 seconds)
+	DefaultTimeout time.Duration
+
+	// ExposeErrors includes the checker errors in the Handler response; they're hidden by default,
+	// since they can include hosts or DSNs and health endpoints are usually unauthenticated
+	ExposeErrors bool
+}
+
+// CheckResult is the result of a single checker
+type CheckResult struct {
+	Status   string        `json:"status"`
+	Critical bool          `json:"critical"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Result is the overall health: down if any critical check failed, degraded if only
+// non-critical checks failed, up otherwise
+type Result struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// Option configures a registered checker
+type Option func(*registration)
+
+// Timeout sets the checker timeout
+func Timeout(timeout time.Duration) Option {
+	return func(r *registration) {
+		r.timeout = timeout
+	}
+}
+
+// NonCritical makes the checker failure degrade the service instead of marking it down
+func NonCritical() Option {
+	return func(r *registration) {
+		r.critical = false
+	}
+}
+
+type registration struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// Health holds the registered checkers
+type Health struct {
+	config Config
+
+	mutex    sync.RWMutex
+	checkers []*registration
+
+	cacheMutex sync.Mutex
+	cached     *Result
+}
+
+// New creates an empty health check
+func New(config Config) *Health {
+	if config.CacheTTL == 0 {
+		config.CacheTTL = time.Second
+	}
+	if config.DefaultTimeout <= 0 {
+		config.DefaultTimeout = 3 * time.Second
+	}
+
+	return &Health{config: config}
+}
+
+// Register adds a checker; checkers are critical unless the `NonCritical` option is used
+func (h *Health) Register(name string, checker Checker, options ...Option) error {
+	r := &registration{name: name, checker: checker, timeout: h.config.DefaultTimeout, critical: true}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	h.mutex.Lock()
+	for _, c := range h.checkers {
+		if c.name == name {
+			h.mutex.Unlock()
+			return ErrDuplicatedChecker
+		}
+	}
+	h.checkers = append(h.checkers, r)
+	h.mutex.Unlock()
+
+	h.invalidate()
+	return nil
+}
+
+// Unregister removes a checker
+func (h *Health) Unregister(name string) {
+	h.mutex.Lock()
+	for i, c := range h.checkers {
+		if c.name == name {
+			h.checkers = append(h.checkers[:i], h.checkers[i+1:]...)
+			break
+		}
+	}
+	h.mutex.Unlock()
+
+	h.invalidate()
+}
+
+// Names returns the names of the registered checkers, sorted
+func (h *Health) Names() []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	names := make([]string, len(h.checkers))
+	for i, c := range h.checkers {
+		names[i] = c.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check runs all the checkers concurrently (or returns the cached result). The result is not
+// cached when ctx is done before the checks finish, since it doesn't reflect the components health.
+func (h *Health) Check(ctx context.Context) Result {
+	h.cacheMutex.Lock()
+	defer h.cacheMutex.Unlock()
+
+	if h.cached != nil && time.Since(h.cached.CheckedAt) < h.config.CacheTTL {
+		return *h.cached
+	}
+
+	result := h.run(ctx)
+	if ctx.Err() == nil {
+		h.cached = &result
+	}
+	return result
+}
+
+// Handler returns an http.Handler that responds with the result as JSON; the status code
+// is 503 when the service is down and 200 otherwise. Errors are included only when
+// Config.ExposeErrors is set.
+func (h *Health) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := h.Check(r.Context())
+		if !h.config.ExposeErrors {
+			result = hideErrors(result)
+		}
+
+		code := http.StatusOK
+		if result.Status == StatusDown {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// Monitor runs the checks every `interval` until ctx is done, calling `callback` with each
+// result; `changed` is true when the overall status differs from the previous run
+func (h *Health) Monitor(ctx context.Context, interval time.Duration, callback func(result Result, changed bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	for {
+		result := h.Check(ctx)
+		callback(result, lastStatus != "" && result.Status != lastStatus)
+		lastStatus = result.Status
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runs all the checkers
+func (h *Health) run(ctx context.Context) Result {
+	h.mutex.RLock()
+	checkers := make([]*registration, len(h.checkers))
+	copy(checkers, h.checkers)
+	h.mutex.RUnlock()
+
+	result := Result{Status: StatusUp, Checks: make(map[string]CheckResult, len(checkers))}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
+
+	for _, c := range checkers {
+		wg.Add(1)
+		go func(c *registration) {
+			defer wg.Done()
+			checkResult := runChecker(ctx, c)
+
+			mutex.Lock()
+			result.Checks[c.name] = checkResult
+			mutex.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	for _, c := range result.Checks {
+		if c.Status == StatusDown {
+			if c.Critical {
+				result.Status = StatusDown
+			} else if result.Status == StatusUp {
+				result.Status = StatusDegraded
+			}
+		}
+	}
+
+	result.CheckedAt = time.Now()
+	return result
+}
+
+// runs a checker with its timeout, recovering from panics
+func runChecker(parent context.Context, c *registration) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(parent, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errChan <- fmt.Errorf("checker panic: %v", p)
+			}
+		}()
+		errChan <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-ctx.Done():
+		if parentErr := parent.Err(); parentErr != nil {
+			err = fmt.Errorf("check cancelled: %s", parentErr.Error())
+		} else {
+			err = fmt.Errorf("timeout after %s", c.timeout)
+		}
+	}
+
+	result = CheckResult{Status: StatusUp, Critical: c.critical, Duration: time.Since(start)}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return
+}
+
+// returns a copy of the result without the checker errors
+func hideErrors(result Result) Result {
+	checks := make(map[string]CheckResult, len(result.Checks))
+	for name, c := range result.Checks {
+		c.Error = ""
+		checks[name] = c
+	}
+
+	result.Checks = checks
+	return result
+}
+
+// discards the cached result; must be called without holding the checkers mutex
+func (h *Health) invalidate() {
+	h.cacheMutex.Lock()
+	h.cached = nil
+	h.cacheMutex.Unlock()
+}
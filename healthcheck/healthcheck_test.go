@@ -0,0 +1,180 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	health := New(Config{CacheTTL: -1})
+
+	ok := CheckerFunc(func(ctx context.Context) error { return nil })
+	failing := CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Nil(t, health.Register("mysql", ok))
+	assert.Equal(t, ErrDuplicatedChecker, health.Register("mysql", ok))
+	assert.Nil(t, health.Register("partner", failing, NonCritical()))
+
+	result := health.Check(context.Background())
+	assert.Equal(t, StatusDegraded, result.Status)
+	assert.Equal(t, StatusUp, result.Checks["mysql"].Status)
+	assert.Equal(t, "connection refused", result.Checks["partner"].Error)
+
+	assert.Nil(t, health.Register("redis", slow, Timeout(10*time.Millisecond)))
+	result = health.Check(context.Background())
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, StatusDown, result.Checks["redis"].Status)
+
+	health.Unregister("redis")
+	assert.Equal(t, []string{"mysql", "partner"}, health.Names())
+}
+
+func TestCheckCache(t *testing.T) {
+	var calls int32
+	health := New(Config{CacheTTL: time.Minute})
+	health.Register("mysql", CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}))
+
+	health.Check(context.Background())
+	health.Check(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// registering a checker discards the cached result
+	health.Register("redis", CheckerFunc(func(ctx context.Context) error { return nil }))
+	health.Check(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCheckCancelled(t *testing.T) {
+	var calls int32
+	health := New(Config{CacheTTL: time.Minute})
+	health.Register("mysql", CheckerFunc(func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}))
+
+	// results of cancelled checks aren't cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := health.Check(ctx)
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Contains(t, result.Checks["mysql"].Error, "cancel")
+
+	result = health.Check(context.Background())
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	health.Check(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// but results of checks that hit their own timeout are
+	health = New(Config{CacheTTL: time.Minute})
+	health.Register("redis", CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	}), Timeout(10*time.Millisecond))
+
+	health.Check(context.Background())
+	result = health.Check(context.Background())
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCheckPanic(t *testing.T) {
+	health := New(Config{})
+	health.Register("broken", CheckerFunc(func(ctx context.Context) error { panic("boom") }))
+
+	result := health.Check(context.Background())
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, "checker panic: boom", result.Checks["broken"].Error)
+}
+
+func TestHandler(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer downstream.Close()
+
+	health := New(Config{ExposeErrors: true})
+	health.Register("partner", HTTPChecker(nil, downstream.URL))
+
+	rec := httptest.NewRecorder()
+	health.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	result := new(Result)
+	json.NewDecoder(rec.Body).Decode(result)
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, "unexpected status code 500", result.Checks["partner"].Error)
+
+	// errors are hidden by default
+	health = New(Config{})
+	health.Register("partner", HTTPChecker(nil, downstream.URL))
+
+	rec = httptest.NewRecorder()
+	health.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "500")
+
+	result = new(Result)
+	json.NewDecoder(rec.Body).Decode(result)
+	assert.Equal(t, StatusDown, result.Checks["partner"].Status)
+	assert.Empty(t, result.Checks["partner"].Error)
+
+	// but kept in the cached result
+	assert.Equal(t, "unexpected status code 500", health.Check(context.Background()).Checks["partner"].Error)
+}
+
+func TestMonitor(t *testing.T) {
+	var healthy int32 = 1
+	health := New(Config{CacheTTL: -1})
+	health.Register("mysql", CheckerFunc(func(ctx context.Context) error {
+		if atomic.LoadInt32(&healthy) == 1 {
+			return nil
+		}
+		return errors.New("down")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	changes := make(chan string, 10)
+
+	var runs int32
+	go health.Monitor(ctx, 5*time.Millisecond, func(result Result, changed bool) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			close(started)
+		}
+		if changed {
+			changes <- result.Status
+		}
+	})
+
+	<-started
+	atomic.StoreInt32(&healthy, 0)
+	select {
+	case status := <-changes:
+		assert.Equal(t, StatusDown, status)
+	case <-time.After(time.Second):
+		t.Error("status change was not reported")
+	}
+	cancel()
+}
@@ -0,0 +1,221 @@
+// Package i18n provides message catalogs with pluralization and locale negotiation for
+// user-facing texts.
+//
+// Catalogs are JSON files named after the locale (ie: "es.json", "pt-BR.json"), usually
+// embedded in the binary:
+//
+//	//go:embed locales/*.json
+//	var locales embed.FS
+//
+//	bundle := i18n.NewBundle("en")
+//	err := bundle.LoadFS(locales, "locales/*.json")
+//
+//	loc := bundle.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+//	text := loc.Plural("items_found", 3, map[string]interface{}{"User": "Pepe"})
+//
+// Each entry of a catalog is either a plain string or an object with plural forms:
+//
+//	{
+//		"welcome": "Hola {{.Name}}",
+//		"items_found": {"zero": "No hay resultados", "one": "{{.Count}} resultado", "other": "{{.Count}} resultados"}
+//	}
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// i18n errors
+var (
+	ErrInvalidLocale  = errors.New("invalid locale")
+	ErrInvalidMessage = errors.New("message must be a string or an object with plural forms")
+)
+
+// Message is a catalog entry; when the message has no plural forms only `Other` is set
+type Message struct {
+	Zero  string `json:"zero"`
+	One   string `json:"one"`
+	Two   string `json:"two"`
+	Few   string `json:"few"`
+	Many  string `json:"many"`
+	Other string `json:"other"`
+}
+
+// Bundle holds the message catalogs of all the locales
+type Bundle struct {
+	defaultLocale string
+
+	mutex     sync.RWMutex
+	catalogs  map[string]map[string]Message
+	templates map[string]*template.Template
+}
+
+// NewBundle creates an empty bundle; `defaultLocale` is used when no other locale matches
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: CanonicalLocale(defaultLocale),
+		catalogs:      make(map[string]map[string]Message),
+		templates:     make(map[string]*template.Template),
+	}
+}
+
+// DefaultLocale returns the bundle default locale
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// LoadFS loads all the catalog files matching `pattern`; the locale is taken from the file name
+func (b *Bundle) LoadFS(fsys fs.FS, pattern string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		locale := strings.TrimSuffix(path.Base(file), path.Ext(file))
+		if err = b.LoadJSON(locale, data); err != nil {
+			return fmt.Errorf("i18n: %s: %s", file, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// LoadJSON loads a JSON catalog for the indicated locale; messages are merged with those already loaded
+func (b *Bundle) LoadJSON(locale string, data []byte) error {
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	messages := make(map[string]Message, len(raw))
+	for key, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			messages[key] = Message{Other: text}
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(value, &msg); err != nil {
+			return fmt.Errorf("%s: %s", key, ErrInvalidMessage.Error())
+		}
+		messages[key] = msg
+	}
+
+	return b.AddMessages(locale, messages)
+}
+
+// AddMessages adds (or replaces) messages for the indicated locale
+func (b *Bundle) AddMessages(locale string, messages map[string]Message) error {
+	if locale = CanonicalLocale(locale); locale == "" {
+		return ErrInvalidLocale
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	catalog, exists := b.catalogs[locale]
+	if !exists {
+		catalog = make(map[string]Message, len(messages))
+		b.catalogs[locale] = catalog
+	}
+
+	for k, v := range messages {
+		catalog[k] = v
+	}
+
+	// parsed templates may belong to replaced messages
+	b.templates = make(map[string]*template.Template)
+
+	return nil
+}
+
+// Locales returns the locales loaded in the bundle
+func (b *Bundle) Locales() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	locales := make([]string, 0, len(b.catalogs))
+	for l := range b.catalogs {
+		locales = append(locales, l)
+	}
+	return locales
+}
+
+// Localizer returns a localizer for the first of the indicated locales that is available
+// (matching also by base language, so "es-UY" uses "es"), or for the default locale
+func (b *Bundle) Localizer(locales ...string) *Localizer {
+	available := b.Locales()
+
+	for _, l := range locales {
+		if match := matchLocale(CanonicalLocale(l), available); match != "" {
+			return &Localizer{bundle: b, locale: match}
+		}
+	}
+
+	return &Localizer{bundle: b, locale: b.defaultLocale}
+}
+
+// FromAcceptLanguage returns a localizer for the best locale according to the Accept-Language header
+func (b *Bundle) FromAcceptLanguage(header string) *Localizer {
+	return b.Localizer(ParseAcceptLanguage(header)...)
+}
+
+// looks for a message in the locale, its base language and the default locale
+func (b *Bundle) lookup(locale, key string) (msg Message, found string) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, l := range []string{locale, baseLanguage(locale), b.defaultLocale} {
+		if catalog, exists := b.catalogs[l]; exists {
+			if msg, exists = catalog[key]; exists {
+				return msg, l
+			}
+		}
+	}
+
+	return
+}
+
+// renders a message text, caching the parsed templates
+func (b *Bundle) render(cacheKey, text string, data interface{}) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+
+	b.mutex.RLock()
+	tpl, exists := b.templates[cacheKey]
+	b.mutex.RUnlock()
+
+	if !exists {
+		var err error
+		if tpl, err = template.New(cacheKey).Option("missingkey=zero").Parse(text); err != nil {
+			return text
+		}
+
+		b.mutex.Lock()
+		b.templates[cacheKey] = tpl
+		b.mutex.Unlock()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return text
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLocales = fstest.MapFS{
+	"locales/en.json": {Data: []byte(`{
+		"welcome": "Welcome {{.Name}}",
+		"items": {"zero": "No items", "one": "{{.Count}} item", "other": "{{.Count}} items"},
+		"only_en": "English only"
+	}`)},
+	"locales/es.json": {Data: []byte(`{
+		"welcome": "Bienvenido {{.Name}}",
+		"items": {"one": "{{.Count}} artículo", "other": "{{.Count}} artículos"}
+	}`)},
+	"locales/pt-BR.json": {Data: []byte(`{
+		"items": {"one": "{{.Count}} item", "other": "{{.Count}} itens"}
+	}`)},
+}
+
+func newTestBundle(t *testing.T) *Bundle {
+	bundle := NewBundle("en")
+	if err := bundle.LoadFS(testLocales, "locales/*.json"); err != nil {
+		t.Fatalf("LoadFS() returned an error: %s", err.Error())
+	}
+	return bundle
+}
+
+func TestCanonicalLocale(t *testing.T) {
+	assert.Equal(t, "es-UY", CanonicalLocale("es_uy"))
+	assert.Equal(t, "pt-BR", CanonicalLocale("PT-br"))
+	assert.Equal(t, "zh-Hant-TW", CanonicalLocale("zh-hant-tw"))
+	assert.Equal(t, "", CanonicalLocale("*"))
+	assert.Equal(t, "", CanonicalLocale(""))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	locales := ParseAcceptLanguage("en;q=0.5, es-UY, pt;q=0.8, *;q=0.1, fr;q=0")
+	assert.Equal(t, []string{"es-UY", "pt", "en"}, locales)
+}
+
+func TestLocalizer(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	// base language match
+	loc := bundle.FromAcceptLanguage("es-UY,en;q=0.5")
+	assert.Equal(t, "es", loc.Locale())
+	assert.Equal(t, "Bienvenido Pepe", loc.T("welcome", map[string]interface{}{"Name": "Pepe"}))
+
+	// fallback to default locale and to the key
+	assert.Equal(t, "English only", loc.T("only_en", nil))
+	assert.Equal(t, "missing_key", loc.T("missing_key", nil))
+	assert.False(t, loc.Has("missing_key"))
+
+	// same language, other region
+	assert.Equal(t, "pt-BR", bundle.Localizer("pt-PT").Locale())
+
+	// no match
+	assert.Equal(t, "en", bundle.Localizer("de").Locale())
+}
+
+func TestPlural(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	en := bundle.Localizer("en")
+	assert.Equal(t, "No items", en.Plural("items", 0, nil))
+	assert.Equal(t, "1 item", en.Plural("items", 1, nil))
+	assert.Equal(t, "5 items", en.Plural("items", 5, nil))
+
+	es := bundle.Localizer("es")
+	assert.Equal(t, "0 artículos", es.Plural("items", 0, nil))
+	assert.Equal(t, "1 artículo", es.Plural("items", 1, map[string]interface{}{}))
+
+	// in brazilian portuguese zero is singular
+	pt := bundle.Localizer("pt-BR")
+	assert.Equal(t, "0 item", pt.Plural("items", 0, nil))
+	assert.Equal(t, "2 itens", pt.Plural("items", 2, nil))
+}
+
+func TestInvalidCatalog(t *testing.T) {
+	bundle := NewBundle("en")
+	assert.NotNil(t, bundle.LoadJSON("en", []byte(`{"key": 10}`)))
+	assert.NotNil(t, bundle.LoadJSON("en", []byte(`not json`)))
+	assert.Equal(t, ErrInvalidLocale, bundle.AddMessages("*", nil))
+}
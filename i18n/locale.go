@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalLocale normalizes a locale identifier: "es_uy" and "ES-uy" become "es-UY".
+// An empty string is returned if the locale is not valid.
+func CanonicalLocale(locale string) string {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(locale), "_", "-", -1), "-")
+
+	if len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return ""
+	}
+
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case len(parts[i]) == 2 && isLetters(parts[i]):
+			// region
+			parts[i] = strings.ToUpper(parts[i])
+		case len(parts[i]) == 4 && isLetters(parts[i]):
+			// script
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		}
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// ParseAcceptLanguage parses an Accept-Language header and returns the locales sorted by
+// preference (quality); wildcards and invalid entries are ignored
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+
+	var entries []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := CanonicalLocale(fields[0])
+		if locale == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, f := range fields[1:] {
+			if f = strings.TrimSpace(f); strings.HasPrefix(f, "q=") {
+				if q, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			entries = append(entries, weighted{locale: locale, quality: quality})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].quality > entries[j].quality
+	})
+
+	locales := make([]string, len(entries))
+	for i := range entries {
+		locales[i] = entries[i].locale
+	}
+	return locales
+}
+
+// returns the best available locale for the requested one: exact match, then the base
+// language ("es-UY" -> "es") and finally any locale of the same language ("es" -> "es-UY")
+func matchLocale(requested string, available []string) string {
+	if requested == "" {
+		return ""
+	}
+
+	sort.Strings(available)
+	base := baseLanguage(requested)
+
+	for _, a := range available {
+		if a == requested {
+			return a
+		}
+	}
+
+	for _, a := range available {
+		if a == base {
+			return a
+		}
+	}
+
+	for _, a := range available {
+		if baseLanguage(a) == base {
+			return a
+		}
+	}
+
+	return ""
+}
+
+// returns the language part of a locale ("pt-BR" -> "pt")
+func baseLanguage(locale string) string {
+	if i := strings.Index(locale, "-"); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package i18n
+
+import "fmt"
+
+// Localizer translates messages to a specific locale
+type Localizer struct {
+	bundle *Bundle
+	locale string
+}
+
+// Locale returns the locale used by the localizer
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T returns the message identified by `key`, rendered with `data` (optional). If the message
+// doesn't exist in the locale nor in the default locale, the key is returned.
+func (l *Localizer) T(key string, data interface{}) string {
+	msg, locale := l.bundle.lookup(l.locale, key)
+	if locale == "" {
+		return key
+	}
+
+	return l.bundle.render(locale+"|"+key, msg.Other, data)
+}
+
+// Plural returns the form of the message that corresponds to `count`. The count is available
+// in the templates as `{{.Count}}` when `data` is nil or a map[string]interface{}.
+//
+// If the message defines a "zero" form it's used for a count of zero, even when the
+// language rules don't have a zero form.
+func (l *Localizer) Plural(key string, count int, data interface{}) string {
+	msg, locale := l.bundle.lookup(l.locale, key)
+	if locale == "" {
+		return key
+	}
+
+	form := PluralFormFor(locale, count)
+	if count == 0 && msg.Zero != "" {
+		form = PluralZero
+	}
+
+	switch d := data.(type) {
+	case nil:
+		data = map[string]interface{}{"Count": count}
+	case map[string]interface{}:
+		withCount := make(map[string]interface{}, len(d)+1)
+		for k, v := range d {
+			withCount[k] = v
+		}
+		withCount["Count"] = count
+		data = withCount
+	}
+
+	return l.bundle.render(fmt.Sprintf("%s|%s|%s", locale, key, form), msg.text(form), data)
+}
+
+// Has returns true if the message exists for the localizer's locale (or the default one)
+func (l *Localizer) Has(key string) bool {
+	_, locale := l.bundle.lookup(l.locale, key)
+	return locale != ""
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import "sync"
+
+// PluralForm is a CLDR plural category
+type PluralForm string
+
+// Plural forms
+const (
+	PluralZero  PluralForm = "zero"
+	PluralOne   PluralForm = "one"
+	PluralTwo   PluralForm = "two"
+	PluralFew   PluralForm = "few"
+	PluralMany  PluralForm = "many"
+	PluralOther PluralForm = "other"
+)
+
+// PluralRule returns the plural form to use for a count
+type PluralRule func(count int) PluralForm
+
+var (
+	pluralRulesMutex sync.RWMutex
+
+	// rules by language (or locale, when it differs from its language); languages not
+	// included here use the "one"/"other" rule
+	pluralRules = map[string]PluralRule{
+		"pt":    zeroOrOneRule,
+		"pt-PT": oneRule,
+		"fr":    zeroOrOneRule,
+		"ja":    noPluralRule,
+		"ko":    noPluralRule,
+		"zh":    noPluralRule,
+	}
+)
+
+// RegisterPluralRule adds (or replaces) the plural rule of a language or locale
+func RegisterPluralRule(locale string, rule PluralRule) {
+	pluralRulesMutex.Lock()
+	pluralRules[CanonicalLocale(locale)] = rule
+	pluralRulesMutex.Unlock()
+}
+
+// PluralFormFor returns the plural form used by the locale for the indicated count
+func PluralFormFor(locale string, count int) PluralForm {
+	pluralRulesMutex.RLock()
+	rule, exists := pluralRules[locale]
+	if !exists {
+		rule, exists = pluralRules[baseLanguage(locale)]
+	}
+	pluralRulesMutex.RUnlock()
+
+	if !exists {
+		rule = oneRule
+	}
+
+	return rule(count)
+}
+
+// returns the text for the indicated form, falling back to "other"
+func (m Message) text(form PluralForm) (text string) {
+	switch form {
+	case PluralZero:
+		text = m.Zero
+	case PluralOne:
+		text = m.One
+	case PluralTwo:
+		text = m.Two
+	case PluralFew:
+		text = m.Few
+	case PluralMany:
+		text = m.Many
+	}
+
+	if text == "" {
+		text = m.Other
+	}
+
+	return
+}
+
+// english, spanish and most european languages
+func oneRule(count int) PluralForm {
+	if count == 1 || count == -1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// brazilian portuguese and french: zero is also singular
+func zeroOrOneRule(count int) PluralForm {
+	if count >= -1 && count <= 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// languages without plural forms
+func noPluralRule(count int) PluralForm {
+	return PluralOther
+}
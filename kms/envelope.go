@@ -0,0 +1,54 @@
+package kms
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// prefix of the serialized envelopes, to change the format in the future
+const envelopePrefix = "kms1"
+
+// envelope is an encrypted value with its wrapped data key, serialized as
+// kms1.<master key version>.<wrapped data key>.<nonce + ciphertext> (base64 url encoded)
+type envelope struct {
+	version int
+	wrapped []byte
+	sealed  []byte
+}
+
+func (e envelope) String() string {
+	return strings.Join([]string{
+		envelopePrefix,
+		strconv.Itoa(e.version),
+		base64.RawURLEncoding.EncodeToString(e.wrapped),
+		base64.RawURLEncoding.EncodeToString(e.sealed),
+	}, ".")
+}
+
+func parseEnvelope(ciphertext string) (e envelope, err error) {
+	parts := strings.Split(ciphertext, ".")
+	if len(parts) != 4 || parts[0] != envelopePrefix {
+		return e, ErrInvalidCiphertext
+	}
+
+	if e.version, err = strconv.Atoi(parts[1]); err != nil {
+		return e, ErrInvalidCiphertext
+	}
+
+	if e.wrapped, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return e, ErrInvalidCiphertext
+	}
+
+	if e.sealed, err = base64.RawURLEncoding.DecodeString(parts[3]); err != nil {
+		return e, ErrInvalidCiphertext
+	}
+
+	return
+}
+
+// Version returns the master key version used to encrypt the value
+func Version(ciphertext string) (int, error) {
+	env, err := parseEnvelope(ciphertext)
+	return env.version, err
+}
@@ -0,0 +1,216 @@
+// Package kms implements envelope encryption: each value is encrypted (AES-256-GCM) with a
+// random data key, and the data key is stored next to the ciphertext wrapped by a master key
+// that lives in a KMS (AWS KMS, GCP KMS) or, for local environments, in the process.
+//
+// Master keys are versioned in a `Keyring`; new values are encrypted with the current version
+// and old values can be re-encrypted after a rotation:
+//
+//	master, _ := kms.NewLocalKeyWrapperFromBase64("local-1", os.Getenv("MASTER_KEY"))
+//	keyring, _ := kms.NewKeyring(kms.Config{Wrappers: map[int]kms.KeyWrapper{1: master}, Current: 1})
+//
+//	ciphertext, err := keyring.EncryptString(ctx, cardNumber, nil)
+//	cardNumber, err = keyring.DecryptString(ctx, ciphertext, nil)
+//
+// Managed KMS are plugged implementing `KeyWrapper` over their SDK (Encrypt/Decrypt calls).
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KMS errors
+var (
+	ErrNoWrappers        = errors.New("keyring has no key wrappers")
+	ErrUnknownVersion    = errors.New("unknown master key version")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext format")
+)
+
+// KeyWrapper encrypts and decrypts data keys with a master key
+type KeyWrapper interface {
+	KeyID() string
+	Wrap(ctx context.Context, key []byte) (wrapped []byte, err error)
+	Unwrap(ctx context.Context, wrapped []byte) (key []byte, err error)
+}
+
+// KeyProvider is implemented by `Keyring`; packages that store encrypted values should depend
+// on this interface
+type KeyProvider interface {
+	Encrypt(ctx context.Context, plaintext, aad []byte) (ciphertext string, err error)
+	Decrypt(ctx context.Context, ciphertext string, aad []byte) (plaintext []byte, err error)
+}
+
+// Config holds the keyring configuration
+type Config struct {
+	// Wrappers are the master keys by version
+	Wrappers map[int]KeyWrapper
+
+	// Current is the version used to encrypt new values
+	Current int
+
+	// DataKeyTTL allows reusing a data key to encrypt several values during that time, saving
+	// calls to the KMS (default 0: a new data key for each value)
+	DataKeyTTL time.Duration
+
+	// CacheSize is the amount of unwrapped data keys kept in memory to decrypt values without
+	// calling the KMS (default 1000, -1 disables the cache)
+	CacheSize int
+}
+
+// Keyring encrypts and decrypts values using versioned master keys
+type Keyring struct {
+	config Config
+
+	mutex     sync.Mutex
+	dataKey   *dataKey
+	unwrapped map[string][]byte
+}
+
+// a data key and its wrapped form
+type dataKey struct {
+	key       []byte
+	wrapped   []byte
+	version   int
+	expiresAt time.Time
+}
+
+// NewKeyring validates the config and creates the keyring
+func NewKeyring(config Config) (*Keyring, error) {
+	if len(config.Wrappers) == 0 {
+		return nil, ErrNoWrappers
+	}
+
+	if _, found := config.Wrappers[config.Current]; !found {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, config.Current)
+	}
+
+	if config.CacheSize == 0 {
+		config.CacheSize = 1000
+	}
+
+	return &Keyring{config: config, unwrapped: make(map[string][]byte)}, nil
+}
+
+// CurrentVersion returns the version used to encrypt new values
+func (k *Keyring) CurrentVersion() int {
+	return k.config.Current
+}
+
+// Encrypt encrypts the plaintext with a data key wrapped by the current master key. The
+// additional data (aad), if any, is authenticated but not stored: the same value must be
+// passed to decrypt (ie: the id of the row, so ciphertexts can't be swapped between rows).
+func (k *Keyring) Encrypt(ctx context.Context, plaintext, aad []byte) (ciphertext string, err error) {
+	dk, err := k.currentDataKey(ctx)
+	if err != nil {
+		return
+	}
+
+	aead, err := newAEAD(dk.key)
+	if err != nil {
+		return
+	}
+
+	sealed, err := seal(aead, plaintext, aad)
+	if err != nil {
+		return
+	}
+
+	return envelope{version: dk.version, wrapped: dk.wrapped, sealed: sealed}.String(), nil
+}
+
+// Decrypt decrypts a value encrypted with any of the master key versions in the keyring
+func (k *Keyring) Decrypt(ctx context.Context, ciphertext string, aad []byte) (plaintext []byte, err error) {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return
+	}
+
+	key, err := k.unwrap(ctx, env.version, env.wrapped)
+	if err != nil {
+		return
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return
+	}
+
+	return open(aead, env.sealed, aad)
+}
+
+// EncryptString encrypts a string
+func (k *Keyring) EncryptString(ctx context.Context, plaintext string, aad []byte) (string, error) {
+	return k.Encrypt(ctx, []byte(plaintext), aad)
+}
+
+// DecryptString decrypts a value encrypted with `EncryptString`
+func (k *Keyring) DecryptString(ctx context.Context, ciphertext string, aad []byte) (string, error) {
+	plaintext, err := k.Decrypt(ctx, ciphertext, aad)
+	return string(plaintext), err
+}
+
+// returns the data key to encrypt, generating a new one if it's not reusable
+func (k *Keyring) currentDataKey(ctx context.Context) (*dataKey, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.dataKey != nil && time.Now().Before(k.dataKey.expiresAt) {
+		return k.dataKey, nil
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.config.Wrappers[k.config.Current].Wrap(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	dk := &dataKey{key: key, wrapped: wrapped, version: k.config.Current}
+	if k.config.DataKeyTTL > 0 {
+		dk.expiresAt = time.Now().Add(k.config.DataKeyTTL)
+		k.dataKey = dk
+	}
+
+	return dk, nil
+}
+
+// unwraps the data key, using the cache when enabled
+func (k *Keyring) unwrap(ctx context.Context, version int, wrapped []byte) (key []byte, err error) {
+	wrapper, found := k.config.Wrappers[version]
+	if !found {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if k.config.CacheSize < 0 {
+		return wrapper.Unwrap(ctx, wrapped)
+	}
+
+	cacheKey := string(wrapped)
+
+	k.mutex.Lock()
+	key, found = k.unwrapped[cacheKey]
+	k.mutex.Unlock()
+
+	if found {
+		return
+	}
+
+	if key, err = wrapper.Unwrap(ctx, wrapped); err != nil {
+		return
+	}
+
+	k.mutex.Lock()
+	if len(k.unwrapped) >= k.config.CacheSize {
+		k.unwrapped = make(map[string][]byte)
+	}
+	k.unwrapped[cacheKey] = key
+	k.mutex.Unlock()
+
+	return
+}
@@ -0,0 +1,167 @@
+package kms
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astropay/go-tools/batch"
+	"github.com/stretchr/testify/assert"
+)
+
+// wrapper that counts the calls, as a remote KMS would be billed
+type countingWrapper struct {
+	KeyWrapper
+	wraps   int32
+	unwraps int32
+}
+
+func (w *countingWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	atomic.AddInt32(&w.wraps, 1)
+	return w.KeyWrapper.Wrap(ctx, key)
+}
+
+func (w *countingWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	atomic.AddInt32(&w.unwraps, 1)
+	return w.KeyWrapper.Unwrap(ctx, wrapped)
+}
+
+func newWrapper(t *testing.T, id string) *countingWrapper {
+	key, _ := GenerateKey()
+	wrapper, err := NewLocalKeyWrapper(id, key)
+	if err != nil {
+		t.Fatalf("NewLocalKeyWrapper() returned an error: %s", err.Error())
+	}
+	return &countingWrapper{KeyWrapper: wrapper}
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := NewKeyring(Config{})
+	assert.Equal(t, ErrNoWrappers, err)
+
+	_, err = NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: newWrapper(t, "k1")}, Current: 2})
+	assert.True(t, errors.Is(err, ErrUnknownVersion))
+
+	_, err = NewLocalKeyWrapper("short", []byte("1234"))
+	assert.Equal(t, ErrInvalidMasterKey, err)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	wrapper := newWrapper(t, "k1")
+	keyring, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: wrapper}, Current: 1})
+
+	ciphertext, err := keyring.EncryptString(ctx, "4111111111111111", []byte("card-1"))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "kms1.1."))
+
+	plaintext, err := keyring.DecryptString(ctx, ciphertext, []byte("card-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "4111111111111111", plaintext)
+
+	// the additional data must match
+	_, err = keyring.DecryptString(ctx, ciphertext, []byte("card-2"))
+	assert.NotNil(t, err)
+
+	_, err = keyring.DecryptString(ctx, "plain value", nil)
+	assert.Equal(t, ErrInvalidCiphertext, err)
+
+	// unwrapped keys are cached
+	keyring.DecryptString(ctx, ciphertext, []byte("card-1"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&wrapper.unwraps))
+
+	// a new data key for each value, unless a TTL is configured
+	other, _ := keyring.EncryptString(ctx, "4111111111111111", []byte("card-1"))
+	assert.NotEqual(t, ciphertext, other)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&wrapper.wraps))
+
+	keyring, _ = NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: wrapper}, Current: 1, DataKeyTTL: time.Minute})
+	keyring.EncryptString(ctx, "a", nil)
+	keyring.EncryptString(ctx, "b", nil)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&wrapper.wraps))
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+	v1, v2 := newWrapper(t, "k1"), newWrapper(t, "k2")
+
+	old, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: v1}, Current: 1})
+	ciphertext, _ := old.EncryptString(ctx, "secret", nil)
+
+	rotated, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: v1, 2: v2}, Current: 2})
+	assert.True(t, rotated.NeedsReencryption(ciphertext))
+
+	result, changed, err := rotated.Reencrypt(ctx, ciphertext, nil)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	version, _ := Version(result)
+	assert.Equal(t, 2, version)
+
+	plaintext, _ := rotated.DecryptString(ctx, result, nil)
+	assert.Equal(t, "secret", plaintext)
+
+	_, changed, _ = rotated.Reencrypt(ctx, result, nil)
+	assert.False(t, changed)
+
+	// old versions can't be decrypted once removed from the keyring
+	current, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{2: v2}, Current: 2})
+	_, err = current.DecryptString(ctx, ciphertext, nil)
+	assert.True(t, errors.Is(err, ErrUnknownVersion))
+}
+
+func TestReencryptProcessor(t *testing.T) {
+	ctx := context.Background()
+	v1, v2 := newWrapper(t, "k1"), newWrapper(t, "k2")
+
+	old, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: v1}, Current: 1})
+	rotated, _ := NewKeyring(Config{Wrappers: map[int]KeyWrapper{1: v1, 2: v2}, Current: 2})
+
+	values := make([]string, 5)
+	for i := range values {
+		keyring := old
+		if i%2 == 0 {
+			keyring = rotated
+		}
+		values[i], _ = keyring.EncryptString(ctx, "secret", nil)
+	}
+
+	reader := batch.ReaderFunc(func(ctx context.Context, offset int64, limit int) ([]interface{}, error) {
+		var items []interface{}
+		for i := offset; i < int64(len(values)) && len(items) < limit; i++ {
+			items = append(items, int(i))
+		}
+		return items, nil
+	})
+
+	processor := rotated.ReencryptProcessor(func(item interface{}) (string, []byte) {
+		return values[item.(int)], nil
+	})
+
+	// the first write fails, so the chunk is processed again
+	var calls, updated int
+	writer := batch.WriterFunc(func(ctx context.Context, items []interface{}) error {
+		if calls++; calls == 1 {
+			return errors.New("connection reset")
+		}
+
+		for _, item := range items {
+			result := item.(Reencrypted)
+			values[result.Item.(int)] = result.Ciphertext
+			updated++
+		}
+		return nil
+	})
+
+	progress, err := batch.NewJob("reencrypt", batch.Config{ChunkSize: 2, MaxRetries: 1, RetryBackoff: time.Millisecond}, reader, processor, writer).Run(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), progress.Retries)
+	assert.Equal(t, 2, updated)
+
+	for _, value := range values {
+		assert.False(t, rotated.NeedsReencryption(value))
+	}
+}
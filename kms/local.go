@@ -0,0 +1,97 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// Local key errors
+var (
+	ErrInvalidMasterKey = errors.New("master key must be 32 bytes (AES-256)")
+	ErrInvalidWrapped   = errors.New("wrapped key is too short")
+)
+
+// LocalKeyWrapper wraps data keys with a master key held by the process (ie: loaded from a
+// secret); use it for development or where a managed KMS is not available
+type LocalKeyWrapper struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper creates a wrapper from a 32 bytes master key
+func NewLocalKeyWrapper(id string, masterKey []byte) (w *LocalKeyWrapper, err error) {
+	if len(masterKey) != 32 {
+		return nil, ErrInvalidMasterKey
+	}
+
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return
+	}
+
+	return &LocalKeyWrapper{id: id, aead: aead}, nil
+}
+
+// NewLocalKeyWrapperFromBase64 creates a wrapper from a base64 encoded master key
+func NewLocalKeyWrapperFromBase64(id, masterKey string) (*LocalKeyWrapper, error) {
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, ErrInvalidMasterKey
+	}
+	return NewLocalKeyWrapper(id, key)
+}
+
+// KeyID returns the wrapper id
+func (w *LocalKeyWrapper) KeyID() string {
+	return w.id
+}
+
+// Wrap encrypts the data key with the master key
+func (w *LocalKeyWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	return seal(w.aead, key, []byte(w.id))
+}
+
+// Unwrap decrypts a data key wrapped with the master key
+func (w *LocalKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return open(w.aead, wrapped, []byte(w.id))
+}
+
+// GenerateKey returns a random 32 bytes key, ie: to create a master key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypts with a random nonce, returning nonce + ciphertext
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// decrypts nonce + ciphertext
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidWrapped
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,72 @@
+package kms
+
+import (
+	"context"
+
+	"github.com/astropay/go-tools/batch"
+)
+
+// NeedsReencryption returns true if the value was encrypted with a master key version other
+// than the current one
+func (k *Keyring) NeedsReencryption(ciphertext string) bool {
+	version, err := Version(ciphertext)
+	return err == nil && version != k.config.Current
+}
+
+// Reencrypt decrypts the value and encrypts it again with the current master key version; values
+// already encrypted with the current version are returned unchanged
+func (k *Keyring) Reencrypt(ctx context.Context, ciphertext string, aad []byte) (result string, changed bool, err error) {
+	if !k.NeedsReencryption(ciphertext) {
+		if _, err = Version(ciphertext); err != nil {
+			return
+		}
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := k.Decrypt(ctx, ciphertext, aad)
+	if err != nil {
+		return
+	}
+
+	if result, err = k.Encrypt(ctx, plaintext, aad); err != nil {
+		return
+	}
+
+	return result, true, nil
+}
+
+// Reencrypted is the result of the ReencryptProcessor: the source item, unchanged, and its new ciphertext
+type Reencrypted struct {
+	Item       interface{}
+	Ciphertext string
+}
+
+// ReencryptProcessor returns a batch processor that re-encrypts the values of the items after a
+// master key rotation. `get` returns the ciphertext of an item and its additional data; items that
+// don't need re-encryption are filtered out, so the writer only receives the ones to update, as
+// Reencrypted values. The items are never modified, so a chunk can be processed again when its
+// write is retried:
+//
+//	processor := keyring.ReencryptProcessor(func(item interface{}) (string, []byte) {
+//		c := item.(*Card)
+//		return c.Number, []byte(c.ID)
+//	})
+//	writer := batch.WriterFunc(func(ctx context.Context, items []interface{}) error {
+//		for _, item := range items {
+//			r := item.(kms.Reencrypted)
+//			// UPDATE cards SET number = r.Ciphertext WHERE id = r.Item.(*Card).ID
+//		}
+//		return nil
+//	})
+func (k *Keyring) ReencryptProcessor(get func(item interface{}) (ciphertext string, aad []byte)) batch.Processor {
+	return batch.ProcessorFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		ciphertext, aad := get(item)
+
+		result, changed, err := k.Reencrypt(ctx, ciphertext, aad)
+		if err != nil || !changed {
+			return nil, err
+		}
+
+		return Reencrypted{Item: item, Ciphertext: result}, nil
+	})
+}
@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchConfig holds the configuration of a `Batcher`
+type BatchConfig struct {
+	// MaxSize is the amount of messages that forces a flush (default 20)
+	MaxSize int
+
+	// FlushInterval is the maximum time a message waits before being sent (default 10 seconds)
+	FlushInterval time.Duration
+
+	// QueueSize is the size of the pending messages buffer (default 1000)
+	QueueSize int
+
+	// Timeout applied to each delivery (default 30 seconds)
+	Timeout time.Duration
+
+	// OnError is called when a batch can't be delivered (optional)
+	OnError func(err error)
+}
+
+// Batcher wraps a notifier so messages are queued and sent asynchronously; messages
+// queued together are grouped in a single notification. Close must be called to flush
+// pending messages before the application ends.
+type Batcher struct {
+	next   Notifier
+	config BatchConfig
+	queue  chan Message
+	done   chan struct{}
+
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewBatcher creates a batcher and starts its delivery goroutine
+func NewBatcher(next Notifier, config BatchConfig) *Batcher {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 20
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 10 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	b := &Batcher{
+		next:   next,
+		config: config,
+		queue:  make(chan Message, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go b.run()
+	return b
+}
+
+// Notify queues the message; it blocks if the queue is full until there's space or ctx is done
+func (b *Batcher) Notify(ctx context.Context, msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	select {
+	case b.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages and waits until all the pending ones are delivered
+func (b *Batcher) Close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return ErrClosed
+	}
+	b.closed = true
+	close(b.queue)
+	b.mutex.Unlock()
+
+	<-b.done
+	return nil
+}
+
+// delivery loop
+func (b *Batcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	pending := make([]Message, 0, b.config.MaxSize)
+
+	for {
+		select {
+		case msg, ok := <-b.queue:
+			if !ok {
+				b.flush(pending)
+				return
+			}
+
+			pending = append(pending, msg)
+			if len(pending) >= b.config.MaxSize {
+				b.flush(pending)
+				pending = pending[:0]
+			}
+
+		case <-ticker.C:
+			b.flush(pending)
+			pending = pending[:0]
+		}
+	}
+}
+
+// sends the pending messages
+func (b *Batcher) flush(pending []Message) {
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.Timeout)
+	defer cancel()
+
+	if err := b.next.Notify(ctx, mergeMessages(pending)); err != nil && b.config.OnError != nil {
+		b.config.OnError(err)
+	}
+}
+
+// groups several messages in a single one, numbered; the level is the most severe of all of them,
+// and the fields of each message are kept, prefixed with its number (ie: "2. Rows")
+func mergeMessages(messages []Message) Message {
+	if len(messages) == 1 {
+		return messages[0]
+	}
+
+	merged := Message{Title: fmt.Sprintf("%d notifications", len(messages)), Level: LevelInfo}
+	lines := make([]string, 0, len(messages))
+
+	for i, m := range messages {
+		if levelSeverity(m.Level) > levelSeverity(merged.Level) {
+			merged.Level = m.Level
+		}
+
+		line := m.Title
+		if m.Text != "" {
+			if line != "" {
+				line += ": "
+			}
+			line += m.Text
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, line))
+
+		for _, f := range m.Fields {
+			merged.Fields = append(merged.Fields, Field{Name: fmt.Sprintf("%d. %s", i+1, f.Name), Value: f.Value})
+		}
+	}
+
+	merged.Text = strings.Join(lines, "\n")
+	return merged
+}
+
+// used to sort levels when merging messages
+func levelSeverity(level string) int {
+	switch level {
+	case LevelError:
+		return 3
+	case LevelWarning:
+		return 2
+	case LevelSuccess:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,138 @@
+// Package notify sends alerts and notifications to chat tools (Slack, Microsoft Teams) and
+// generic webhooks, so batch jobs and services don't need their own HTTP snippets for it.
+//
+//	slack := notify.NewSlackNotifier(notify.SlackConfig{WebhookURL: "https://hooks.slack.com/services/..."})
+//	err := slack.Notify(ctx, notify.Message{Title: "Settlement finished", Text: "1520 rows processed", Level: notify.LevelInfo})
+//
+// Notifiers can be wrapped to limit the amount of messages sent (`NewRateLimiter`) or to group
+// them and send them asynchronously (`NewBatcher`).
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Message levels
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+	LevelSuccess = "success"
+)
+
+// Notify errors
+var (
+	ErrEmptyMessage = errors.New("message must have a title or a text")
+	ErrRateLimited  = errors.New("notification discarded by rate limiter")
+	ErrClosed       = errors.New("notifier is closed")
+)
+
+// WebhookError is returned when the destination responds with a non 2xx status code
+type WebhookError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Field is a key/value pair shown along with the message
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is the notification to send
+type Message struct {
+	Title  string
+	Text   string
+	Level  string
+	Fields []Field
+}
+
+// Notifier is the common interface implemented by all the notification channels
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NotifierFunc is an adapter to allow the use of ordinary functions as notifiers
+type NotifierFunc func(ctx context.Context, msg Message) error
+
+// Notify calls f(ctx, msg)
+func (f NotifierFunc) Notify(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Validate checks the message has something to send
+func (m Message) Validate() error {
+	if m.Title == "" && m.Text == "" {
+		return ErrEmptyMessage
+	}
+	return nil
+}
+
+// returns a default HTTP client when none is configured
+func resolveClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return client
+}
+
+// marshals `payload` as JSON and posts it to `url`
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, client, http.MethodPost, url, body, map[string]string{"Content-Type": "application/json"})
+}
+
+// sends the body to the indicated URL; any non 2xx response is returned as a `WebhookError`
+func post(ctx context.Context, client *http.Client, method, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return &WebhookError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return nil
+}
+
+// maps the message level to a color, used by Slack and Teams
+func levelColor(level string) string {
+	switch level {
+	case LevelWarning:
+		return "#FFA500"
+	case LevelError:
+		return "#D00000"
+	case LevelSuccess:
+		return "#2EB886"
+	default:
+		return "#439FE0"
+	}
+}
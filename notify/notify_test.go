@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// starts a server that keeps the bodies received
+func newRecordingServer(status int) (*httptest.Server, func() []string) {
+	var (
+		mutex  sync.Mutex
+		bodies []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(body))
+		mutex.Unlock()
+		w.WriteHeader(status)
+	}))
+
+	return server, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), bodies...)
+	}
+}
+
+func TestSlackNotify(t *testing.T) {
+	server, bodies := newRecordingServer(http.StatusOK)
+	defer server.Close()
+
+	slack := NewSlackNotifier(SlackConfig{WebhookURL: server.URL, Channel: "#alerts"})
+	err := slack.Notify(context.Background(), Message{Title: "Job failed", Text: "settlement", Level: LevelError, Fields: []Field{{Name: "Rows", Value: "10"}}})
+	if err != nil {
+		t.Fatalf("Notify() returned an error: %s", err.Error())
+	}
+
+	payload := new(SlackMessage)
+	json.Unmarshal([]byte(bodies()[0]), payload)
+	assert.Equal(t, "#alerts", payload.Channel)
+	if assert.Len(t, payload.Attachments, 1) {
+		assert.Equal(t, "Job failed", payload.Attachments[0].Title)
+		assert.Equal(t, levelColor(LevelError), payload.Attachments[0].Color)
+		assert.Equal(t, "Rows", payload.Attachments[0].Fields[0].Title)
+	}
+
+	err = slack.Send(context.Background(), SlackMessage{Blocks: []SlackBlock{SlackHeader("Report"), SlackDivider(), SlackMarkdownSection("*done*")}})
+	assert.Nil(t, err)
+	assert.Contains(t, bodies()[1], `"type":"header"`)
+}
+
+func TestTeamsNotify(t *testing.T) {
+	server, bodies := newRecordingServer(http.StatusOK)
+	defer server.Close()
+
+	teams := NewTeamsNotifier(TeamsConfig{WebhookURL: server.URL})
+	if err := teams.Notify(context.Background(), Message{Title: "Job failed", Level: LevelWarning}); err != nil {
+		t.Fatalf("Notify() returned an error: %s", err.Error())
+	}
+
+	card := new(teamsMessageCard)
+	json.Unmarshal([]byte(bodies()[0]), card)
+	assert.Equal(t, "MessageCard", card.Type)
+	assert.Equal(t, "FFA500", card.ThemeColor)
+	assert.Equal(t, "Job failed", card.Summary)
+}
+
+func TestWebhookNotify(t *testing.T) {
+	server, bodies := newRecordingServer(http.StatusOK)
+	defer server.Close()
+
+	webhook, err := NewWebhookNotifier(WebhookConfig{URL: server.URL, BodyTemplate: `{"summary": {{json .Title}}}`})
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() returned an error: %s", err.Error())
+	}
+
+	assert.Nil(t, webhook.Notify(context.Background(), Message{Title: `quoted "title"`}))
+	assert.Equal(t, `{"summary": "quoted \"title\""}`, bodies()[0])
+
+	// errors
+	_, err = NewWebhookNotifier(WebhookConfig{URL: server.URL, BodyTemplate: `{{.Title`})
+	assert.NotNil(t, err)
+
+	failing, _ := newRecordingServer(http.StatusInternalServerError)
+	defer failing.Close()
+
+	webhook, _ = NewWebhookNotifier(WebhookConfig{URL: failing.URL})
+	err = webhook.Notify(context.Background(), Message{Text: "text"})
+	if webhookErr, ok := err.(*WebhookError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusInternalServerError, webhookErr.StatusCode)
+	}
+
+	assert.Equal(t, ErrEmptyMessage, webhook.Notify(context.Background(), Message{}))
+}
+
+func TestTemplate(t *testing.T) {
+	tpl := MustTemplate("Job {{.Job}} failed", "{{.Errors}} errors")
+	msg, err := tpl.Render(LevelError, map[string]interface{}{"Job": "settlement", "Errors": 3})
+	if err != nil {
+		t.Fatalf("Render() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, "Job settlement failed", msg.Title)
+	assert.Equal(t, "3 errors", msg.Text)
+	assert.Equal(t, LevelError, msg.Level)
+
+	_, err = NewTemplate("{{.Job", "")
+	assert.NotNil(t, err)
+}
+
+func TestRateLimiter(t *testing.T) {
+	var sent []Message
+	next := NotifierFunc(func(ctx context.Context, msg Message) error {
+		sent = append(sent, msg)
+		return nil
+	})
+
+	now := time.Now()
+	limiter := NewRateLimiter(next, 2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	msg := Message{Text: "alert"}
+	assert.Nil(t, limiter.Notify(context.Background(), msg))
+	assert.Nil(t, limiter.Notify(context.Background(), msg))
+	assert.Equal(t, ErrRateLimited, limiter.Notify(context.Background(), msg))
+	assert.Equal(t, 1, limiter.Discarded())
+
+	// next window
+	now = now.Add(time.Minute)
+	assert.Nil(t, limiter.Notify(context.Background(), msg))
+	assert.Len(t, sent, 3)
+	assert.Equal(t, "1", sent[2].Fields[0].Value)
+}
+
+func TestBatcher(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		sent  []Message
+	)
+	next := NotifierFunc(func(ctx context.Context, msg Message) error {
+		mutex.Lock()
+		sent = append(sent, msg)
+		mutex.Unlock()
+		return nil
+	})
+
+	batcher := NewBatcher(next, BatchConfig{MaxSize: 3, FlushInterval: time.Hour})
+	for i, level := range []string{LevelInfo, LevelError, LevelWarning, LevelInfo} {
+		msg := Message{Title: "title", Text: level, Level: level}
+		if i > 0 {
+			msg.Fields = []Field{{Name: "Rows", Value: fmt.Sprint(i)}}
+		}
+		assert.Nil(t, batcher.Notify(context.Background(), msg))
+	}
+	batcher.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, "3 notifications", sent[0].Title)
+		assert.Equal(t, LevelError, sent[0].Level)
+		assert.Equal(t, "1. title: info\n2. title: error\n3. title: warning", sent[0].Text)
+		assert.Equal(t, []Field{{Name: "2. Rows", Value: "1"}, {Name: "3. Rows", Value: "2"}}, sent[0].Fields)
+		assert.Equal(t, "title", sent[1].Title)
+	}
+
+	assert.Equal(t, ErrClosed, batcher.Notify(context.Background(), Message{Text: "late"}))
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter wraps a notifier allowing at most `limit` notifications per time window; those
+// exceeding the limit are discarded (returning ErrRateLimited). The first notification sent
+// after some were discarded carries a field with the amount of discarded messages.
+type RateLimiter struct {
+	next   Notifier
+	limit  int
+	window time.Duration
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	count       int
+	discarded   int
+
+	// used for testing
+	now func() time.Time
+}
+
+// NewRateLimiter creates a rate limited notifier
+func NewRateLimiter(next Notifier, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{next: next, limit: limit, window: window, now: time.Now}
+}
+
+// Notify sends the message if the limit for the current window wasn't reached
+func (r *RateLimiter) Notify(ctx context.Context, msg Message) error {
+	r.mutex.Lock()
+
+	now := r.now()
+	if now.Sub(r.windowStart) >= r.window {
+		r.windowStart = now
+		r.count = 0
+	}
+
+	if r.count >= r.limit {
+		r.discarded++
+		r.mutex.Unlock()
+		return ErrRateLimited
+	}
+
+	r.count++
+	discarded := r.discarded
+	r.discarded = 0
+	r.mutex.Unlock()
+
+	if discarded > 0 {
+		fields := make([]Field, 0, len(msg.Fields)+1)
+		fields = append(fields, msg.Fields...)
+		msg.Fields = append(fields, Field{Name: "Discarded notifications", Value: fmt.Sprint(discarded)})
+	}
+
+	return r.next.Notify(ctx, msg)
+}
+
+// Discarded returns the amount of notifications discarded since the last one sent
+func (r *RateLimiter) Discarded() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.discarded
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// SlackConfig holds the configuration of a Slack incoming webhook
+type SlackConfig struct {
+	WebhookURL string
+
+	// Channel, Username and IconEmoji override the webhook defaults (optional)
+	Channel   string
+	Username  string
+	IconEmoji string
+
+	// HTTPClient used for the requests; defaults to a client with a 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+// SlackText is a Slack text object
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackBlock is a Slack layout block (section, header, divider, context...)
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+// SlackAttachmentField is a field shown inside an attachment
+type SlackAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// SlackAttachment is a (legacy) Slack message attachment
+type SlackAttachment struct {
+	Color    string                 `json:"color,omitempty"`
+	Title    string                 `json:"title,omitempty"`
+	Text     string                 `json:"text,omitempty"`
+	Fallback string                 `json:"fallback,omitempty"`
+	Fields   []SlackAttachmentField `json:"fields,omitempty"`
+	Blocks   []SlackBlock           `json:"blocks,omitempty"`
+}
+
+// SlackMessage is the payload sent to the Slack webhook
+type SlackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Blocks      []SlackBlock      `json:"blocks,omitempty"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+}
+
+// SlackNotifier sends notifications to Slack using incoming webhooks
+type SlackNotifier struct {
+	config SlackConfig
+	client *http.Client
+}
+
+// NewSlackNotifier creates a new Slack notifier
+func NewSlackNotifier(config SlackConfig) *SlackNotifier {
+	return &SlackNotifier{config: config, client: resolveClient(config.HTTPClient)}
+}
+
+// Notify sends the message as a colored attachment (color depends on the level)
+func (s *SlackNotifier) Notify(ctx context.Context, msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	attachment := SlackAttachment{
+		Color:    levelColor(msg.Level),
+		Title:    msg.Title,
+		Text:     msg.Text,
+		Fallback: msg.Title,
+	}
+
+	for _, f := range msg.Fields {
+		attachment.Fields = append(attachment.Fields, SlackAttachmentField{Title: f.Name, Value: f.Value, Short: len(f.Value) < 40})
+	}
+
+	return s.Send(ctx, SlackMessage{Attachments: []SlackAttachment{attachment}})
+}
+
+// Send sends a Slack specific message, useful when blocks are required
+func (s *SlackNotifier) Send(ctx context.Context, msg SlackMessage) error {
+	if msg.Channel == "" {
+		msg.Channel = s.config.Channel
+	}
+	if msg.Username == "" {
+		msg.Username = s.config.Username
+	}
+	if msg.IconEmoji == "" {
+		msg.IconEmoji = s.config.IconEmoji
+	}
+
+	return postJSON(ctx, s.client, s.config.WebhookURL, msg)
+}
+
+// SlackMarkdownSection is a helper that creates a section block with markdown text
+func SlackMarkdownSection(text string) SlackBlock {
+	return SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text}}
+}
+
+// SlackHeader is a helper that creates a header block
+func SlackHeader(text string) SlackBlock {
+	return SlackBlock{Type: "header", Text: &SlackText{Type: "plain_text", Text: text}}
+}
+
+// SlackDivider is a helper that creates a divider block
+func SlackDivider() SlackBlock {
+	return SlackBlock{Type: "divider"}
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TeamsConfig holds the configuration of a Microsoft Teams incoming webhook
+type TeamsConfig struct {
+	WebhookURL string
+
+	// HTTPClient used for the requests; defaults to a client with a 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+}
+
+// Teams "MessageCard" payload
+type teamsMessageCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Sections   []teamsSection `json:"sections,omitempty"`
+}
+
+// TeamsNotifier sends notifications to Microsoft Teams using incoming webhooks
+type TeamsNotifier struct {
+	config TeamsConfig
+	client *http.Client
+}
+
+// NewTeamsNotifier creates a new Teams notifier
+func NewTeamsNotifier(config TeamsConfig) *TeamsNotifier {
+	return &TeamsNotifier{config: config, client: resolveClient(config.HTTPClient)}
+}
+
+// Notify sends the message as a message card
+func (t *TeamsNotifier) Notify(ctx context.Context, msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	card := teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: strings.TrimPrefix(levelColor(msg.Level), "#"),
+		Summary:    msg.Title,
+		Title:      msg.Title,
+		Text:       msg.Text,
+	}
+
+	if card.Summary == "" {
+		card.Summary = msg.Text
+	}
+
+	if len(msg.Fields) > 0 {
+		section := teamsSection{}
+		for _, f := range msg.Fields {
+			section.Facts = append(section.Facts, teamsFact{Name: f.Name, Value: f.Value})
+		}
+		card.Sections = append(card.Sections, section)
+	}
+
+	return postJSON(ctx, t.client, t.config.WebhookURL, card)
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+)
+
+// functions available in all the templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Template builds messages from text/template definitions, so the same alert
+// can be reused with different data:
+//
+//	tpl := notify.MustTemplate("Job {{.Job}} failed", "{{.Errors}} errors processing {{.File}}")
+//	msg, err := tpl.Render(notify.LevelError, data)
+type Template struct {
+	title *template.Template
+	text  *template.Template
+}
+
+// NewTemplate parses the title and text templates
+func NewTemplate(title, text string) (t *Template, err error) {
+	t = new(Template)
+
+	if t.title, err = template.New("title").Funcs(templateFuncs).Parse(title); err != nil {
+		return nil, err
+	}
+
+	if t.text, err = template.New("text").Funcs(templateFuncs).Parse(text); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// MustTemplate is like NewTemplate but panics if any of the templates is invalid
+func MustTemplate(title, text string) *Template {
+	t, err := NewTemplate(title, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render executes the templates with the indicated data and returns the resulting message
+func (t *Template) Render(level string, data interface{}, fields ...Field) (msg Message, err error) {
+	buf := new(bytes.Buffer)
+
+	if err = t.title.Execute(buf, data); err != nil {
+		return
+	}
+	msg.Title = buf.String()
+
+	buf.Reset()
+	if err = t.text.Execute(buf, data); err != nil {
+		return
+	}
+	msg.Text = buf.String()
+
+	msg.Level = level
+	msg.Fields = fields
+	return
+}
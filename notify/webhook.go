@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"text/template"
+)
+
+// WebhookConfig holds the configuration of a generic webhook
+type WebhookConfig struct {
+	URL string
+
+	// Method defaults to POST
+	Method string
+
+	// Headers sent with every request; Content-Type defaults to application/json
+	Headers map[string]string
+
+	// BodyTemplate is a text/template used to build the request body; the template receives
+	// the `Message`. A `json` function is available to escape values, ie:
+	//
+	//	{"summary": {{json .Title}}, "details": {{json .Text}}}
+	//
+	// When empty, the message is sent as JSON.
+	BodyTemplate string
+
+	// HTTPClient used for the requests; defaults to a client with a 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+// WebhookNotifier sends notifications to any HTTP endpoint
+type WebhookNotifier struct {
+	config   WebhookConfig
+	client   *http.Client
+	template *template.Template
+}
+
+// webhook default body
+type webhookPayload struct {
+	Title  string            `json:"title,omitempty"`
+	Text   string            `json:"text,omitempty"`
+	Level  string            `json:"level,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// NewWebhookNotifier creates a new webhook notifier; an error is returned if the body template is invalid
+func NewWebhookNotifier(config WebhookConfig) (w *WebhookNotifier, err error) {
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+
+	w = &WebhookNotifier{config: config, client: resolveClient(config.HTTPClient)}
+
+	if config.BodyTemplate != "" {
+		if w.template, err = template.New("webhook").Funcs(templateFuncs).Parse(config.BodyTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Notify sends the message to the webhook
+func (w *WebhookNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	if err = msg.Validate(); err != nil {
+		return
+	}
+
+	var body []byte
+
+	if w.template != nil {
+		buf := new(bytes.Buffer)
+		if err = w.template.Execute(buf, msg); err != nil {
+			return
+		}
+		body = buf.Bytes()
+	} else {
+		payload := webhookPayload{Title: msg.Title, Text: msg.Text, Level: msg.Level}
+		if len(msg.Fields) > 0 {
+			payload.Fields = make(map[string]string, len(msg.Fields))
+			for _, f := range msg.Fields {
+				payload.Fields[f.Name] = f.Value
+			}
+		}
+
+		if body, err = json.Marshal(payload); err != nil {
+			return
+		}
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for k, v := range w.config.Headers {
+		headers[k] = v
+	}
+
+	return post(ctx, w.client, w.config.Method, w.config.URL, body, headers)
+}
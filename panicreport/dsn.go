@@ -0,0 +1,53 @@
+package panicreport
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DSN errors
+var (
+	ErrInvalidDSN = errors.New("invalid Sentry DSN (expected scheme://public_key@host/project_id)")
+)
+
+// dsn is a parsed Sentry DSN
+type dsn struct {
+	publicKey string
+	storeURL  string
+}
+
+// parses a DSN like https://public_key@o123.ingest.sentry.io/456
+func parseDSN(raw string) (d *dsn, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidDSN
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, ErrInvalidDSN
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return nil, ErrInvalidDSN
+	}
+
+	// Sentry can be hosted under a path prefix: the project id is the last segment
+	prefix, projectID := "", path
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		prefix, projectID = "/"+path[:idx], path[idx+1:]
+	}
+
+	d = &dsn{
+		publicKey: u.User.Username(),
+		storeURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+	}
+	return
+}
+
+// returns the X-Sentry-Auth header value
+func (d *dsn) authHeader() string {
+	return fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s", sdkName, sdkVersion, d.publicKey)
+}
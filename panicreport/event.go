@@ -0,0 +1,258 @@
+package panicreport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/astropay/go-tools/auth"
+)
+
+// Event levels
+const (
+	LevelFatal   = "fatal"
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelInfo    = "info"
+)
+
+// Event is the payload sent to Sentry
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Exception   []Exception            `json:"exception,omitempty"`
+	Request     *Request               `json:"request,omitempty"`
+	User        *User                  `json:"user,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	SDK         map[string]string      `json:"sdk,omitempty"`
+}
+
+// Exception is the error or panic value of the event
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace holds the frames, ordered from the oldest call to the newest
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a single call in the stack
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Request is the HTTP request being served when the event happened
+type Request struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// User is the authenticated caller
+type User struct {
+	ID       string `json:"id,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// value sent instead of the sensitive query parameters
+const filteredValue = "[Filtered]"
+
+// headers and query parameters containing any of these words (case insensitive) are never sent
+// to Sentry, ie: Authorization, Proxy-Authorization, Set-Cookie, X-Auth-Token or access_token
+var sensitiveWords = []string{"auth", "cookie", "token", "secret", "password", "passwd", "signature", "api-key", "api_key", "apikey", "session"}
+
+// returns true if the header or query parameter can carry credentials; `extra` holds the
+// additional names configured (lowercase)
+func isSensitive(name string, extra map[string]bool) bool {
+	name = strings.ToLower(name)
+	if extra[name] {
+		return true
+	}
+
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// builds the exception for an error or a panic value, with the stack of the caller
+func newException(value interface{}, skip int) Exception {
+	var exception Exception
+
+	switch v := value.(type) {
+	case error:
+		exception.Type = reflect.TypeOf(v).String()
+		exception.Value = v.Error()
+	case string:
+		exception.Type = "panic"
+		exception.Value = v
+	default:
+		exception.Type = "panic"
+		exception.Value = fmt.Sprint(v)
+	}
+
+	exception.Stacktrace = stacktrace(skip + 1)
+	return exception
+}
+
+// returns the stack of the caller, skipping `skip` frames
+func stacktrace(skip int) *Stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	if n == 0 {
+		return nil
+	}
+
+	frames := runtime.CallersFrames(pcs[:n])
+	result := make([]Frame, 0, n)
+	for {
+		frame, more := frames.Next()
+
+		// when panicking, the frames above the panic belong to the deferred recover call
+		if frame.Function == "runtime.gopanic" {
+			result = result[:0]
+		}
+
+		module, function := splitFunction(frame.Function)
+		if module != "runtime" {
+			result = append(result, Frame{
+				Function: function,
+				Module:   module,
+				Filename: shortFilename(frame.File),
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    !strings.Contains(frame.File, "/pkg/mod/") && !strings.Contains(frame.File, "/vendor/"),
+			})
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	// Sentry expects the newest frame last
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return &Stacktrace{Frames: result}
+}
+
+// splits "github.com/org/pkg.(*Type).Method" into the package path and the function name
+func splitFunction(name string) (module, function string) {
+	lastSlash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[lastSlash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += lastSlash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// returns the last two elements of the path (ie: "common/common.go")
+func shortFilename(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) <= 2 {
+		return path
+	}
+	return strings.Join(parts[len(parts)-2:], "/")
+}
+
+// adds the request, user and tags stored in the context to the event; `sensitive` holds the
+// additional headers and query parameters to filter (lowercase)
+func enrich(ctx context.Context, event *Event, sensitive map[string]bool) {
+	if ctx == nil {
+		return
+	}
+
+	if r, ok := ctx.Value(requestKey{}).(*http.Request); ok {
+		event.Request = newRequest(r, sensitive)
+	}
+
+	if principal, ok := auth.FromContext(ctx); ok && principal != nil {
+		event.User = &User{ID: principal.ID, Username: principal.Name}
+	}
+
+	if tags, ok := ctx.Value(tagsKey{}).(map[string]string); ok {
+		for k, v := range tags {
+			event.Tags[k] = v
+		}
+	}
+}
+
+func newRequest(r *http.Request, sensitive map[string]bool) *Request {
+	request := &Request{
+		Method:      r.Method,
+		QueryString: scrubQuery(r.URL.RawQuery, sensitive),
+		Headers:     make(map[string]string),
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	request.URL = scheme + "://" + r.Host + r.URL.Path
+
+	for name := range r.Header {
+		if !isSensitive(name, sensitive) {
+			request.Headers[name] = r.Header.Get(name)
+		}
+	}
+
+	return request
+}
+
+// replaces the values of the sensitive query parameters; a query that can't be parsed is dropped
+func scrubQuery(rawQuery string, sensitive map[string]bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+
+	for name, values := range query {
+		if isSensitive(name, sensitive) {
+			for i := range values {
+				values[i] = filteredValue
+			}
+		}
+	}
+
+	return query.Encode()
+}
+
+// returns a random 32 hex chars id
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,199 @@
+package panicreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astropay/go-tools/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type codedError struct {
+	code string
+}
+
+func (e *codedError) Error() string { return "coded error " + e.code }
+func (e *codedError) Code() string  { return e.code }
+
+// fake Sentry server that records the received events
+type sentryServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	auth   string
+	events []*Event
+}
+
+func newSentryServer() *sentryServer {
+	s := new(sentryServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := new(Event)
+		json.NewDecoder(r.Body).Decode(event)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.auth = r.Header.Get("X-Sentry-Auth")
+		if r.URL.Path == "/api/42/store/" {
+			s.events = append(s.events, event)
+		}
+	}))
+	return s
+}
+
+func (s *sentryServer) dsn() string {
+	return "http://public@" + s.Listener.Addr().String() + "/42"
+}
+
+func (s *sentryServer) received() []*Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.events
+}
+
+func TestParseDSN(t *testing.T) {
+	d, err := parseDSN("https://abc123@o1.ingest.sentry.io/456")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://o1.ingest.sentry.io/api/456/store/", d.storeURL)
+	assert.Equal(t, "abc123", d.publicKey)
+
+	d, err = parseDSN("https://abc123@sentry.example.com/prefix/456")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sentry.example.com/prefix/api/456/store/", d.storeURL)
+
+	for _, invalid := range []string{"sentry.io/456", "https://sentry.io/456", "https://abc@sentry.io", "ftp://abc@sentry.io/1"} {
+		if _, err := parseDSN(invalid); err != ErrInvalidDSN {
+			t.Errorf("parseDSN(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sentry := newSentryServer()
+	defer sentry.Close()
+
+	reporter, err := New(Config{DSN: sentry.dsn(), Environment: "test", SensitiveFields: []string{"card", "X-Card"}})
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err.Error())
+	}
+
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/payments?id=1&access_token=secret&card=4111", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Proxy-Authorization", "Basic secret")
+	req.Header.Set("X-Auth-Token", "secret")
+	req.Header.Set("X-Card", "4111")
+	req.Header.Set("User-Agent", "tests")
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{ID: "merchant-1"}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	assert.Nil(t, reporter.Close(time.Second))
+
+	events := sentry.received()
+	if assert.Len(t, events, 1) {
+		event := events[0]
+		assert.Equal(t, LevelFatal, event.Level)
+		assert.Equal(t, "test", event.Environment)
+		assert.Equal(t, "boom", event.Exception[0].Value)
+		assert.Equal(t, "http://api.example.com/payments", event.Request.URL)
+		assert.Equal(t, "access_token=%5BFiltered%5D&card=%5BFiltered%5D&id=1", event.Request.QueryString)
+		assert.Equal(t, "tests", event.Request.Headers["User-Agent"])
+		assert.Empty(t, event.Request.Headers["Authorization"])
+		assert.Empty(t, event.Request.Headers["Proxy-Authorization"])
+		assert.Empty(t, event.Request.Headers["X-Auth-Token"])
+		assert.Empty(t, event.Request.Headers["X-Card"])
+		assert.Equal(t, "merchant-1", event.User.ID)
+
+		frames := event.Exception[0].Stacktrace.Frames
+		assert.Equal(t, "TestMiddleware.func1", frames[len(frames)-1].Function)
+	}
+	assert.Contains(t, sentry.auth, "sentry_key=public")
+}
+
+func TestCaptureError(t *testing.T) {
+	sentry := newSentryServer()
+	defer sentry.Close()
+
+	reporter, _ := New(Config{DSN: sentry.dsn(), IgnoreCodes: []string{"not_found"}, SampleRate: Rate(0.5)})
+	samples := []float64{0.1, 0.9}
+	reporter.random = func() (v float64) {
+		v, samples = samples[0], samples[1:]
+		return
+	}
+
+	ctx := WithTags(context.Background(), map[string]string{"country": "BR"})
+
+	assert.Empty(t, reporter.CaptureError(ctx, nil))
+	assert.Empty(t, reporter.CaptureError(ctx, &codedError{"not_found"}))
+	assert.NotEmpty(t, reporter.CaptureError(ctx, &codedError{"insufficient_funds"}))
+	assert.Empty(t, reporter.CaptureError(ctx, errors.New("sampled out")))
+
+	assert.True(t, reporter.Flush(time.Second))
+
+	events := sentry.received()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "insufficient_funds", events[0].Tags["error_code"])
+		assert.Equal(t, "BR", events[0].Tags["country"])
+		assert.Equal(t, "*panicreport.codedError", events[0].Exception[0].Type)
+	}
+
+	reporter.Close(time.Second)
+	assert.Equal(t, ErrReporterClosed, reporter.Close(time.Second))
+}
+
+func TestSampleRate(t *testing.T) {
+	sentry := newSentryServer()
+	defer sentry.Close()
+
+	// an explicit zero discards all the errors, but not the panics
+	reporter, _ := New(Config{DSN: sentry.dsn(), SampleRate: Rate(0)})
+	reporter.random = func() float64 { return 0 }
+
+	assert.Empty(t, reporter.CaptureError(context.Background(), errors.New("sampled out")))
+	assert.NotEmpty(t, reporter.CapturePanic(context.Background(), "boom"))
+	reporter.Close(time.Second)
+	assert.Len(t, sentry.received(), 1)
+
+	// no rate reports everything
+	reporter, _ = New(Config{DSN: sentry.dsn()})
+	reporter.random = func() float64 { return 0.99 }
+	assert.NotEmpty(t, reporter.CaptureError(context.Background(), errors.New("reported")))
+	reporter.Close(time.Second)
+	assert.Len(t, sentry.received(), 2)
+}
+
+func TestWrap(t *testing.T) {
+	sentry := newSentryServer()
+	defer sentry.Close()
+
+	reporter, _ := New(Config{DSN: sentry.dsn()})
+	job := reporter.Wrap(context.Background(), func(ctx context.Context) error {
+		panic(errors.New("nil pointer"))
+	})
+
+	err := job()
+	if assert.IsType(t, &PanicError{}, err) {
+		assert.Equal(t, "panic: nil pointer", err.Error())
+	}
+
+	reporter.Close(time.Second)
+	assert.Len(t, sentry.received(), 1)
+}
+
+func TestDisabled(t *testing.T) {
+	reporter, err := New(Config{})
+	assert.Nil(t, err)
+	assert.False(t, reporter.Enabled())
+	assert.Empty(t, reporter.CaptureMessage(context.Background(), LevelInfo, "hello"))
+	assert.Nil(t, reporter.Close(time.Second))
+}
@@ -0,0 +1,98 @@
+package panicreport
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestKey struct{}
+type tagsKey struct{}
+
+// WithRequest returns a copy of ctx carrying the HTTP request, reported along with the events
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// WithTags returns a copy of ctx carrying the tags (merged with the ones already stored),
+// reported along with the events
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string)
+	if current, ok := ctx.Value(tagsKey{}).(map[string]string); ok {
+		for k, v := range current {
+			merged[k] = v
+		}
+	}
+
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// Recover reports the panic, if any, and stops it; it must be called with defer:
+//
+//	defer reporter.Recover(ctx)
+func (r *Reporter) Recover(ctx context.Context) {
+	if value := recover(); value != nil {
+		r.CapturePanic(ctx, value)
+	}
+}
+
+// RecoverAndRepanic reports the panic, if any, and panics again with the same value, so the
+// process still crashes
+func (r *Reporter) RecoverAndRepanic(ctx context.Context) {
+	if value := recover(); value != nil {
+		r.CapturePanic(ctx, value)
+		r.Flush(flushTimeout)
+		panic(value)
+	}
+}
+
+// Go runs fn in a new goroutine, reporting its panic instead of crashing the process
+func (r *Reporter) Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer r.Recover(ctx)
+		fn(ctx)
+	}()
+}
+
+// Wrap returns a function that runs fn reporting its panic and its error, to be used with job
+// runners (ie: scheduled tasks or worker pools)
+func (r *Reporter) Wrap(ctx context.Context, fn func(ctx context.Context) error) func() error {
+	return func() (err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				r.CapturePanic(ctx, value)
+				err = &PanicError{Value: value}
+			}
+		}()
+
+		if err = fn(ctx); err != nil {
+			r.CaptureError(ctx, err)
+		}
+		return
+	}
+}
+
+// Middleware recovers the panics of the next handler, reports them with the request data and
+// responds with a 500 status code. Requests carry the reporter context, so handlers can report
+// errors with `reporter.CaptureError(r.Context(), err)`.
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.WithContext(WithRequest(req.Context(), req))
+
+		defer func() {
+			if value := recover(); value != nil {
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+
+				r.CapturePanic(req.Context(), value)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
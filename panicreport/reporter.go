@@ -0,0 +1,330 @@
+// Package panicreport reports panics and errors to Sentry, enriched with the request, the
+// authenticated principal and custom tags stored in the context.
+//
+//	reporter, err := panicreport.New(panicreport.Config{DSN: os.Getenv("SENTRY_DSN"), Environment: "production", SampleRate: panicreport.Rate(0.25)})
+//	defer reporter.Close(5 * time.Second)
+//
+//	http.ListenAndServe(":8080", reporter.Middleware(mux))  // recovers and reports handler panics
+//	reporter.Go(ctx, worker.Run)                             // recovers and reports goroutine panics
+//	reporter.CaptureError(ctx, err)                          // reports an error
+//
+// Events are sent in background; an empty DSN disables the reporting (ie: local environments).
+package panicreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sdkName    = "go-tools.panicreport"
+	sdkVersion = "1.0.0"
+
+	// time to wait for the panic event to be sent before crashing
+	flushTimeout = 2 * time.Second
+)
+
+// Reporter errors
+var (
+	ErrReporterClosed = errors.New("reporter is closed")
+	ErrQueueFull      = errors.New("event discarded, the queue is full")
+)
+
+// Config holds the reporter configuration
+type Config struct {
+	// DSN of the Sentry project; empty disables the reporter
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string // default: hostname
+
+	// SampleRate is the fraction of errors reported, between 0 and 1 (see Rate); nil reports all
+	// of them, and zero none. Panics are always reported.
+	SampleRate *float64
+
+	// SensitiveFields lists additional headers and query parameters whose values are never sent;
+	// the ones that look like credentials (ie: Authorization, Set-Cookie, X-Auth-Token or
+	// access_token) are always filtered
+	SensitiveFields []string
+
+	// IgnoreCodes lists the error codes (see `CodedError`) that are never reported, ie: expected
+	// business errors
+	IgnoreCodes []string
+
+	// BeforeSend can modify the event before it's sent, or discard it returning nil
+	BeforeSend func(event *Event) *Event
+
+	// QueueSize is the amount of events waiting to be sent (default 100); when the queue is
+	// full new events are discarded
+	QueueSize int
+
+	// OnError is invoked when an event can't be delivered or is discarded because the queue is full
+	OnError func(err error)
+
+	HTTPClient *http.Client
+}
+
+// Rate returns a pointer to the sample rate, to be used in Config.SampleRate
+func Rate(rate float64) *float64 {
+	return &rate
+}
+
+// PanicError is returned by the functions created with `Wrap` when they panic
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// CodedError is implemented by errors that carry an error code; the code is reported as the
+// "error_code" tag
+type CodedError interface {
+	error
+	Code() string
+}
+
+// Reporter sends events to Sentry
+type Reporter struct {
+	config     Config
+	dsn        *dsn
+	ignored    map[string]bool
+	sensitive  map[string]bool
+	sampleRate float64
+
+	queue   chan *Event
+	pending int64
+	wg      sync.WaitGroup
+
+	mutex  sync.RWMutex
+	closed bool
+
+	random func() float64
+}
+
+// New creates a reporter and starts the goroutine that sends the events
+func New(config Config) (r *Reporter, err error) {
+	r = &Reporter{config: config, ignored: make(map[string]bool), sensitive: make(map[string]bool), sampleRate: 1, random: rand.Float64}
+
+	if config.DSN != "" {
+		if r.dsn, err = parseDSN(config.DSN); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.SampleRate != nil {
+		r.sampleRate = math.Max(0, math.Min(1, *config.SampleRate))
+	}
+
+	if r.config.QueueSize <= 0 {
+		r.config.QueueSize = 100
+	}
+
+	if r.config.ServerName == "" {
+		r.config.ServerName, _ = os.Hostname()
+	}
+
+	if r.config.HTTPClient == nil {
+		r.config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	for _, code := range config.IgnoreCodes {
+		r.ignored[code] = true
+	}
+
+	for _, name := range config.SensitiveFields {
+		r.sensitive[strings.ToLower(name)] = true
+	}
+
+	r.queue = make(chan *Event, r.config.QueueSize)
+	r.wg.Add(1)
+	go r.run()
+
+	return
+}
+
+// Enabled returns false if the reporter was created without a DSN
+func (r *Reporter) Enabled() bool {
+	return r.dsn != nil
+}
+
+// CapturePanic reports a recovered panic value; it returns the event id
+func (r *Reporter) CapturePanic(ctx context.Context, value interface{}) string {
+	event := r.newEvent(ctx, LevelFatal)
+	event.Exception = []Exception{newException(value, 1)}
+	event.Tags["panic"] = "true"
+	return r.enqueue(event)
+}
+
+// CaptureError reports an error, subject to the sample rate; it returns the event id, or an
+// empty string if the event was discarded
+func (r *Reporter) CaptureError(ctx context.Context, err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var coded CodedError
+	if errors.As(err, &coded) && r.ignored[coded.Code()] {
+		return ""
+	}
+
+	if r.random() >= r.sampleRate {
+		return ""
+	}
+
+	event := r.newEvent(ctx, LevelError)
+	event.Exception = []Exception{newException(err, 1)}
+	if coded != nil {
+		event.Tags["error_code"] = coded.Code()
+	}
+	return r.enqueue(event)
+}
+
+// CaptureMessage reports a message with the indicated level; it returns the event id
+func (r *Reporter) CaptureMessage(ctx context.Context, level, message string) string {
+	event := r.newEvent(ctx, level)
+	event.Message = message
+	return r.enqueue(event)
+}
+
+// Flush waits until the queued events are sent or the timeout expires; it returns false on timeout
+func (r *Reporter) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&r.pending) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+// Close stops accepting events and waits until the queued ones are sent or the timeout expires
+func (r *Reporter) Close(timeout time.Duration) error {
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return ErrReporterClosed
+	}
+	r.closed = true
+	close(r.queue)
+	r.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout sending %d queued events", atomic.LoadInt64(&r.pending))
+	}
+}
+
+func (r *Reporter) newEvent(ctx context.Context, level string) *Event {
+	event := &Event{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC(),
+		Level:       level,
+		Platform:    "go",
+		Environment: r.config.Environment,
+		Release:     r.config.Release,
+		ServerName:  r.config.ServerName,
+		Tags:        make(map[string]string),
+		SDK:         map[string]string{"name": sdkName, "version": sdkVersion},
+	}
+
+	enrich(ctx, event, r.sensitive)
+	return event
+}
+
+// adds the event to the sending queue
+func (r *Reporter) enqueue(event *Event) string {
+	if r.dsn == nil {
+		return ""
+	}
+
+	if r.config.BeforeSend != nil {
+		if event = r.config.BeforeSend(event); event == nil {
+			return ""
+		}
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.closed {
+		return ""
+	}
+
+	atomic.AddInt64(&r.pending, 1)
+	select {
+	case r.queue <- event:
+		return event.EventID
+	default:
+		atomic.AddInt64(&r.pending, -1)
+		r.reportError(ErrQueueFull)
+		return ""
+	}
+}
+
+func (r *Reporter) run() {
+	defer r.wg.Done()
+
+	for event := range r.queue {
+		if err := r.send(event); err != nil {
+			r.reportError(err)
+		}
+		atomic.AddInt64(&r.pending, -1)
+	}
+}
+
+// posts the event to the Sentry store endpoint
+func (r *Reporter) send(event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.dsn.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.dsn.authHeader())
+
+	resp, err := r.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("sentry responded with status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+func (r *Reporter) reportError(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
@@ -0,0 +1,99 @@
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+
+	"github.com/astropay/go-tools/files"
+)
+
+// ProfileType is the kind of profile to capture
+type ProfileType string
+
+// Profile types
+const (
+	ProfileCPU       ProfileType = "cpu"
+	ProfileHeap      ProfileType = "heap"
+	ProfileTrace     ProfileType = "trace"
+	ProfileGoroutine ProfileType = "goroutine"
+)
+
+// Capture errors
+var (
+	ErrInvalidProfileType = errors.New("invalid profile type (cpu, heap, trace or goroutine)")
+)
+
+// Capture writes a profile to a new file in `dir` and returns its path. CPU and trace profiles
+// are recorded during `duration` (or until ctx is done); heap and goroutine profiles are
+// taken immediately. An empty `dir` means "profiles" under the application path.
+func Capture(ctx context.Context, profileType ProfileType, dir string, duration time.Duration) (path string, err error) {
+	ext := ".pprof"
+	switch profileType {
+	case ProfileCPU, ProfileHeap, ProfileGoroutine:
+	case ProfileTrace:
+		ext = ".trace"
+	default:
+		return "", ErrInvalidProfileType
+	}
+
+	if dir == "" {
+		dir = filepath.Join(files.GetAppPath(), "profiles")
+	}
+
+	if err = files.EnsureDir(dir); err != nil {
+		return
+	}
+
+	path = filepath.Join(dir, fmt.Sprintf("%s-%s%s", profileType, time.Now().Format("20060102-150405.000"), ext))
+
+	var f *os.File
+	if f, err = os.Create(path); err != nil {
+		return "", err
+	}
+
+	switch profileType {
+	case ProfileCPU:
+		if err = pprof.StartCPUProfile(f); err == nil {
+			wait(ctx, duration)
+			pprof.StopCPUProfile()
+		}
+	case ProfileTrace:
+		if err = trace.Start(f); err == nil {
+			wait(ctx, duration)
+			trace.Stop()
+		}
+	case ProfileHeap:
+		runtime.GC()
+		err = pprof.Lookup("heap").WriteTo(f, 0)
+	case ProfileGoroutine:
+		err = pprof.Lookup("goroutine").WriteTo(f, 0)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		files.DeleteFile(path)
+		return "", err
+	}
+
+	return
+}
+
+func wait(ctx context.Context, duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
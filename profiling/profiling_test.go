@@ -0,0 +1,95 @@
+package profiling
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/astropay/go-tools/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerRequiresAuth(t *testing.T) {
+	assert.Equal(t, ErrNoAuth, NewServer(Config{}).Enable())
+}
+
+func TestServerEnableDisable(t *testing.T) {
+	server := NewServer(Config{Address: "127.0.0.1:0", Username: "ops", Password: "secret"})
+	if err := server.Enable(); err != nil {
+		t.Fatalf("Enable() returned an error: %s", err.Error())
+	}
+	assert.Equal(t, ErrAlreadyEnabled, server.Enable())
+
+	url := "http://" + server.Addr().String() + "/debug/stats"
+
+	// no credentials
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %s", err.Error())
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// valid credentials
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.SetBasicAuth("ops", "secret")
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatalf("request failed: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	stats := new(Stats)
+	json.NewDecoder(resp.Body).Decode(stats)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, stats.Goroutines > 0)
+
+	assert.Nil(t, server.Disable())
+	assert.False(t, server.Enabled())
+	assert.Equal(t, ErrNotEnabled, server.Disable())
+}
+
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "profiles")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	for _, profileType := range []ProfileType{ProfileCPU, ProfileHeap, ProfileTrace, ProfileGoroutine} {
+		path, err := Capture(context.Background(), profileType, dir, 10*time.Millisecond)
+		if err != nil {
+			t.Errorf("Capture(%s) returned an error: %s", profileType, err.Error())
+			continue
+		}
+		assert.True(t, files.Exists(path))
+	}
+
+	_, err = Capture(context.Background(), "memory", dir, time.Second)
+	assert.Equal(t, ErrInvalidProfileType, err)
+}
+
+func TestCaptureHandler(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "profiles")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	handler, _ := NewServer(Config{Username: "ops", Password: "secret", ProfilesDir: dir}).Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/debug/capture?type=heap", nil)
+	req.SetBasicAuth("ops", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	response := make(map[string]string)
+	json.NewDecoder(rec.Body).Decode(&response)
+	assert.True(t, files.Exists(response["file"]))
+}
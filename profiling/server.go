@@ -0,0 +1,191 @@
+// Package profiling exposes the runtime diagnostics of a service (pprof endpoints, runtime
+// stats and on-demand profile captures) on a separate, authenticated port.
+//
+// The server can be enabled and disabled at runtime, ie: from a config flag or a signal:
+//
+//	prof := profiling.NewServer(profiling.Config{Address: "127.0.0.1:6060", Username: "ops", Password: secret})
+//	if config.ProfilingEnabled {
+//		prof.Enable()
+//	}
+//	profiling.ToggleOnSignal(prof, syscall.SIGUSR1, nil)
+package profiling
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"time"
+)
+
+// Profiling errors
+var (
+	ErrNoAuth         = errors.New("profiling server requires authentication (basic auth or middleware)")
+	ErrAlreadyEnabled = errors.New("profiling server is already enabled")
+	ErrNotEnabled     = errors.New("profiling server is not enabled")
+)
+
+// Config holds the profiling server configuration
+type Config struct {
+	// Address to listen on; it should be bound to an internal interface (default 127.0.0.1:6060)
+	Address string
+
+	// Basic auth credentials
+	Username string
+	Password string
+
+	// Middleware used to authenticate the requests instead of basic auth (ie: auth.APIKeyAuthenticator.Middleware)
+	Middleware func(http.Handler) http.Handler
+
+	// ProfilesDir is where captured profiles are written (default: "profiles" in the app path)
+	ProfilesDir string
+}
+
+// Server is the profiling HTTP server
+type Server struct {
+	config Config
+
+	mutex    sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer creates a profiling server; it doesn't listen until `Enable()` is called
+func NewServer(config Config) *Server {
+	if config.Address == "" {
+		config.Address = "127.0.0.1:6060"
+	}
+
+	return &Server{config: config}
+}
+
+// Enable starts listening
+func (s *Server) Enable() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.server != nil {
+		return ErrAlreadyEnabled
+	}
+
+	handler, err := s.Handler()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.server = &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(listener)
+
+	return nil
+}
+
+// Disable stops listening; in-flight requests (ie: a CPU profile) are cancelled
+func (s *Server) Disable() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.server == nil {
+		return ErrNotEnabled
+	}
+
+	err := s.server.Close()
+	s.server = nil
+	s.listener = nil
+	return err
+}
+
+// Enabled returns true if the server is listening
+func (s *Server) Enabled() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.server != nil
+}
+
+// Addr returns the address the server is listening on, or nil if it's not enabled
+func (s *Server) Addr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Handler returns the authenticated handler with all the endpoints, to be mounted in
+// another server if needed:
+//
+//	/debug/pprof/...   standard pprof endpoints
+//	/debug/stats       runtime stats snapshot (JSON)
+//	/debug/capture     captures a profile to a file (?type=cpu|heap|trace|goroutine&seconds=N)
+func (s *Server) Handler() (http.Handler, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/stats", statsHandler)
+	mux.HandleFunc("/debug/capture", s.captureHandler)
+
+	switch {
+	case s.config.Middleware != nil:
+		return s.config.Middleware(mux), nil
+	case s.config.Username != "" && s.config.Password != "":
+		return basicAuth(s.config.Username, s.config.Password, mux), nil
+	default:
+		return nil, ErrNoAuth
+	}
+}
+
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Snapshot())
+}
+
+func (s *Server) captureHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	duration := 30 * time.Second
+	if seconds := r.URL.Query().Get("seconds"); seconds != "" {
+		d, err := time.ParseDuration(seconds + "s")
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid seconds", http.StatusBadRequest)
+			return
+		}
+		duration = d
+	}
+
+	path, err := Capture(r.Context(), ProfileType(r.URL.Query().Get("type")), s.config.ProfilesDir, duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"file": path})
+}
+
+func basicAuth(username, password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(username)) != 1 || subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="profiling"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package profiling
+
+import (
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+)
+
+// process start time, used to compute the uptime
+var startTime = time.Now()
+
+// Stats is a snapshot of the runtime statistics
+type Stats struct {
+	Uptime      time.Duration `json:"uptime_ns"`
+	NumCPU      int           `json:"num_cpu"`
+	GOMAXPROCS  int           `json:"gomaxprocs"`
+	Goroutines  int           `json:"goroutines"`
+	HeapAlloc   uint64        `json:"heap_alloc"`
+	HeapInuse   uint64        `json:"heap_inuse"`
+	HeapObjects uint64        `json:"heap_objects"`
+	TotalAlloc  uint64        `json:"total_alloc"`
+	Sys         uint64        `json:"sys"`
+	NumGC       uint32        `json:"num_gc"`
+	PauseTotal  time.Duration `json:"gc_pause_total_ns"`
+	LastGC      time.Time     `json:"last_gc"`
+	GoVersion   string        `json:"go_version"`
+	CapturedAt  time.Time     `json:"captured_at"`
+}
+
+// Snapshot returns the current runtime statistics. Note that reading the memory stats
+// stops the world for a short time; don't call it in a tight loop.
+func Snapshot() Stats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := Stats{
+		Uptime:      time.Since(startTime),
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapInuse:   mem.HeapInuse,
+		HeapObjects: mem.HeapObjects,
+		TotalAlloc:  mem.TotalAlloc,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+		PauseTotal:  time.Duration(mem.PauseTotalNs),
+		GoVersion:   runtime.Version(),
+		CapturedAt:  time.Now(),
+	}
+
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC))
+	}
+
+	return stats
+}
+
+// ToggleOnSignal enables or disables the server each time the process receives the signal
+// (ie: syscall.SIGUSR1). The returned function stops listening for the signal.
+func ToggleOnSignal(s *Server, sig os.Signal, onError func(err error)) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, sig)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				var err error
+				if s.Enabled() {
+					err = s.Disable()
+				} else {
+					err = s.Enable()
+				}
+
+				if err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/astropay/go-tools/geo"
+)
+
+// Response headers
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// SetHeaders sets the standard rate limit headers from the result; durations are rounded up
+// to seconds
+func SetHeaders(h http.Header, result Result) {
+	h.Set(HeaderLimit, strconv.FormatInt(result.Limit, 10))
+	h.Set(HeaderRemaining, strconv.FormatInt(result.Remaining, 10))
+	h.Set(HeaderReset, strconv.FormatInt(int64(math.Ceil(result.ResetAfter.Seconds())), 10))
+
+	if !result.Allowed {
+		h.Set(HeaderRetryAfter, strconv.FormatInt(int64(math.Ceil(result.RetryAfter.Seconds())), 10))
+	}
+}
+
+// KeyFunc returns the key that identifies the requests sharing a limit; an empty key skips the
+// rate limit for the request
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP identifies the requests by the caller's address; X-Forwarded-For is used only on
+// requests coming from the trusted proxies (see geo.ClientIP)
+func KeyByIP(trustedProxies []*net.IPNet) KeyFunc {
+	return func(r *http.Request) string {
+		if ip := geo.ClientIP(r, trustedProxies); ip != nil {
+			return "ip:" + ip.String()
+		}
+		return "ip:" + r.RemoteAddr
+	}
+}
+
+// KeyByHeader identifies the requests by the value of a header (ie: X-Api-Key)
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if value := r.Header.Get(name); value != "" {
+			return strings.ToLower(name) + ":" + value
+		}
+		return ""
+	}
+}
+
+// MiddlewareConfig holds the middleware configuration
+type MiddlewareConfig struct {
+	Limiter Limiter
+
+	// Key identifies the requests sharing a limit (default KeyByIP(TrustedProxies))
+	Key KeyFunc
+
+	// TrustedProxies are the networks of the proxies in front of the service, used by the
+	// default key to take the caller's address from X-Forwarded-For (see geo.ParseNetworks)
+	TrustedProxies []*net.IPNet
+
+	// FailClosed rejects the requests when the limiter fails (ie: Redis is down); by default
+	// they are allowed
+	FailClosed bool
+
+	// OnError is invoked when the limiter fails
+	OnError func(r *http.Request, err error)
+}
+
+// Middleware rejects the requests over the limit with 429 (Too Many Requests), setting the rate
+// limit headers on all the responses
+func Middleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	if config.Key == nil {
+		config.Key = KeyByIP(config.TrustedProxies)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := config.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := config.Limiter.Allow(r.Context(), key)
+			if err != nil {
+				if config.OnError != nil {
+					config.OnError(r, err)
+				}
+
+				if config.FailClosed {
+					http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			SetHeaders(w.Header(), result)
+
+			if !result.Allowed {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Package ratelimit implements distributed rate limiters backed by Redis, so the limits are
+// shared by all the replicas of a service. Two algorithms are available:
+//
+//   - sliding window: at most `Rate` requests in any `Period` (precise, stores one entry per request)
+//   - token bucket: `Rate` tokens per `Period` are refilled up to `Burst` (constant memory, allows bursts)
+//
+// Both run atomically in Redis through Lua scripts, using the Redis clock.
+//
+//	limiter := ratelimit.NewTokenBucket(redisClient, ratelimit.Config{
+//		Limit:     ratelimit.Limit{Rate: 100, Period: time.Minute, Burst: 20},
+//		Overrides: map[string]ratelimit.Limit{"merchant:42": {Rate: 1000, Period: time.Minute}},
+//	})
+//	proxies, err := geo.ParseNetworks("10.0.0.0/8")
+//	handler = ratelimit.Middleware(ratelimit.MiddlewareConfig{Limiter: limiter, TrustedProxies: proxies})(handler)
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Rate limit errors
+var (
+	ErrInvalidLimit  = errors.New("limit must have a positive rate and period")
+	ErrExceedsLimit  = errors.New("requested amount exceeds the limit capacity")
+	ErrInvalidAmount = errors.New("requested amount must be positive")
+)
+
+// Limit is the allowed rate
+type Limit struct {
+	Rate   int64
+	Period time.Duration
+
+	// Burst is the token bucket capacity (default: Rate); ignored by the sliding window
+	Burst int64
+}
+
+// PerSecond returns a limit of `rate` requests per second
+func PerSecond(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute returns a limit of `rate` requests per minute
+func PerMinute(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// Validate checks the limit values
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Period < time.Millisecond || l.Burst < 0 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// capacity returns the max amount that can be allowed at once
+func (l Limit) capacity() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+
+	// RetryAfter is the time to wait until the request would be allowed (zero when allowed)
+	RetryAfter time.Duration
+
+	// ResetAfter is the time until the limit is fully available again
+	ResetAfter time.Duration
+}
+
+// Limiter checks if the requests identified by a key are allowed
+type Limiter interface {
+	// Allow is shorthand for AllowN(ctx, key, 1)
+	Allow(ctx context.Context, key string) (Result, error)
+
+	// AllowN checks if `n` requests are allowed now and, if so, consumes them
+	AllowN(ctx context.Context, key string, n int64) (Result, error)
+}
@@ -0,0 +1,295 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/astropay/go-tools/geo"
+	"github.com/astropay/go-tools/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory fixed window limiter, to test the middleware
+type fakeLimiter struct {
+	limit int64
+	used  map[string]int64
+	err   error
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return f.AllowN(ctx, key, 1)
+}
+
+func (f *fakeLimiter) AllowN(ctx context.Context, key string, n int64) (Result, error) {
+	if f.err != nil {
+		return Result{}, f.err
+	}
+
+	result := Result{Limit: f.limit, ResetAfter: 1500 * time.Millisecond}
+	if f.used[key]+n <= f.limit {
+		f.used[key] += n
+		result.Allowed = true
+	} else {
+		result.RetryAfter = 200 * time.Millisecond
+	}
+	result.Remaining = f.limit - f.used[key]
+	return result, nil
+}
+
+func TestLimit(t *testing.T) {
+	assert.Nil(t, PerSecond(10).Validate())
+	assert.Equal(t, ErrInvalidLimit, Limit{Rate: 10}.Validate())
+	assert.Equal(t, ErrInvalidLimit, Limit{Period: time.Minute}.Validate())
+	assert.Equal(t, int64(10), PerMinute(10).capacity())
+	assert.Equal(t, int64(25), Limit{Rate: 10, Period: time.Second, Burst: 25}.capacity())
+}
+
+func TestRedisLimiterChecks(t *testing.T) {
+	// nothing listening, so any call reaching Redis fails
+	client := redis.NewFromClient(goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}), "test:")
+	defer client.Close()
+
+	config := Config{Limit: PerSecond(5), Overrides: map[string]Limit{"merchant:42": {Rate: 100, Period: time.Second, Burst: 200}}}
+	window := NewSlidingWindow(client, config)
+	bucket := NewTokenBucket(client, config)
+	ctx := context.Background()
+
+	assert.Equal(t, int64(100), bucket.LimitFor("merchant:42").Rate)
+	assert.Equal(t, int64(5), bucket.LimitFor("merchant:1").Rate)
+
+	_, err := window.AllowN(ctx, "merchant:1", 0)
+	assert.Equal(t, ErrInvalidAmount, err)
+
+	_, err = window.AllowN(ctx, "merchant:1", 6)
+	assert.Equal(t, ErrExceedsLimit, err)
+
+	// burst only applies to the token bucket
+	_, err = window.AllowN(ctx, "merchant:42", 150)
+	assert.Equal(t, ErrExceedsLimit, err)
+
+	_, err = bucket.AllowN(ctx, "merchant:42", 150)
+	assert.NotNil(t, err)
+	assert.NotEqual(t, ErrExceedsLimit, err)
+
+	_, err = NewTokenBucket(client, Config{}).Allow(ctx, "merchant:1")
+	assert.Equal(t, ErrInvalidLimit, err)
+}
+
+// miniredis server with a fake clock, used by the TIME command of the scripts
+type testServer struct {
+	*miniredis.Miniredis
+	now time.Time
+}
+
+// moves the clock, and expires the keys
+func (s *testServer) advance(d time.Duration) {
+	s.now = s.now.Add(d)
+	s.SetTime(s.now)
+	s.FastForward(d)
+}
+
+func newTestRedis(t *testing.T) (*redis.Client, *testServer) {
+	server := &testServer{Miniredis: miniredis.RunT(t), now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	server.SetTime(server.now)
+
+	client, err := redis.New(redis.Config{Address: server.Addr(), KeyPrefix: "test:"})
+	if err != nil {
+		t.Fatalf("redis.New() returned an error: %s", err.Error())
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client, server
+}
+
+func TestSlidingWindow(t *testing.T) {
+	client, server := newTestRedis(t)
+	limiter := NewSlidingWindow(client, Config{Limit: PerSecond(3)})
+	ctx := context.Background()
+
+	for remaining := int64(2); remaining >= 0; remaining-- {
+		result, err := limiter.Allow(ctx, "merchant:1")
+		assert.Nil(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+		assert.Equal(t, time.Second, result.ResetAfter)
+	}
+	assert.Equal(t, time.Second, server.TTL("test:ratelimit:merchant:1"))
+
+	// denied until the oldest request leaves the window
+	result, err := limiter.Allow(ctx, "merchant:1")
+	assert.Nil(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	server.advance(400 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "merchant:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 600*time.Millisecond, result.RetryAfter)
+
+	// other keys have their own window
+	result, _ = limiter.AllowN(ctx, "merchant:2", 3)
+	assert.True(t, result.Allowed)
+
+	server.advance(300 * time.Millisecond)
+	result, _ = limiter.AllowN(ctx, "merchant:2", 1)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 700*time.Millisecond, result.RetryAfter)
+
+	server.advance(300 * time.Millisecond)
+	result, _ = limiter.AllowN(ctx, "merchant:1", 2)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(1), result.Remaining)
+
+	// the key expires once the window is empty
+	server.advance(time.Second)
+	assert.False(t, server.Exists("test:ratelimit:merchant:1"))
+
+	limiter.Allow(ctx, "merchant:1")
+	assert.Nil(t, limiter.Reset(ctx, "merchant:1"))
+	assert.False(t, server.Exists("test:ratelimit:merchant:1"))
+}
+
+func TestTokenBucket(t *testing.T) {
+	client, server := newTestRedis(t)
+	limiter := NewTokenBucket(client, Config{Limit: Limit{Rate: 10, Period: time.Second, Burst: 5}})
+	ctx := context.Background()
+
+	// the bucket starts full
+	result, err := limiter.AllowN(ctx, "merchant:1", 5)
+	assert.Nil(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(5), result.Limit)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Equal(t, 500*time.Millisecond, result.ResetAfter)
+	assert.Equal(t, 500*time.Millisecond, server.TTL("test:ratelimit:merchant:1"))
+
+	result, err = limiter.Allow(ctx, "merchant:1")
+	assert.Nil(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 100*time.Millisecond, result.RetryAfter)
+
+	// tokens are refilled at 10 per second
+	server.advance(250 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "merchant:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(1), result.Remaining)
+
+	result, _ = limiter.AllowN(ctx, "merchant:1", 2)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 50*time.Millisecond, result.RetryAfter)
+
+	// up to the burst
+	server.advance(10 * time.Second)
+	assert.False(t, server.Exists("test:ratelimit:merchant:1"))
+
+	result, _ = limiter.Allow(ctx, "merchant:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(4), result.Remaining)
+}
+
+func TestSetHeaders(t *testing.T) {
+	h := http.Header{}
+	SetHeaders(h, Result{Allowed: false, Limit: 100, Remaining: 0, RetryAfter: 1200 * time.Millisecond, ResetAfter: 30 * time.Second})
+
+	assert.Equal(t, "100", h.Get(HeaderLimit))
+	assert.Equal(t, "0", h.Get(HeaderRemaining))
+	assert.Equal(t, "30", h.Get(HeaderReset))
+	assert.Equal(t, "2", h.Get(HeaderRetryAfter))
+
+	h = http.Header{}
+	SetHeaders(h, Result{Allowed: true, Limit: 100, Remaining: 99})
+	assert.Empty(t, h.Get(HeaderRetryAfter))
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := &fakeLimiter{limit: 2, used: make(map[string]int64)}
+	handler := Middleware(MiddlewareConfig{Limiter: limiter, Key: KeyByHeader("X-Api-Key")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("key-1").Code)
+	rec := request("key-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(HeaderRemaining))
+
+	rec = request("key-1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(HeaderRetryAfter))
+
+	// other keys have their own limit, and requests without key aren't limited
+	assert.Equal(t, http.StatusOK, request("key-2").Code)
+	assert.Equal(t, http.StatusOK, request("").Code)
+	assert.Equal(t, int64(2), limiter.used["x-api-key:key-1"])
+}
+
+func TestMiddlewareFailure(t *testing.T) {
+	var failures int
+	limiter := &fakeLimiter{err: errors.New("connection refused")}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	onError := func(r *http.Request, err error) { failures++ }
+
+	rec := httptest.NewRecorder()
+	Middleware(MiddlewareConfig{Limiter: limiter, OnError: onError})(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	Middleware(MiddlewareConfig{Limiter: limiter, OnError: onError, FailClosed: true})(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, 2, failures)
+}
+
+func TestKeyByIP(t *testing.T) {
+	proxies, _ := geo.ParseNetworks("10.0.0.0/8")
+	key := KeyByIP(proxies)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "200.40.1.1:5000"
+	assert.Equal(t, "ip:200.40.1.1", key(req))
+
+	// spoofed by an untrusted caller
+	req.Header.Set("X-Forwarded-For", "190.64.1.1")
+	assert.Equal(t, "ip:200.40.1.1", key(req))
+	assert.Equal(t, "ip:200.40.1.1", KeyByIP(nil)(req))
+
+	// behind a trusted proxy, only the entries it appended are used
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 190.64.1.1, 10.0.0.1")
+	assert.Equal(t, "ip:190.64.1.1", key(req))
+}
+
+func TestMiddlewareDefaultKey(t *testing.T) {
+	proxies, _ := geo.ParseNetworks("10.0.0.1")
+	limiter := &fakeLimiter{limit: 1, used: make(map[string]int64)}
+	handler := Middleware(MiddlewareConfig{Limiter: limiter, TrustedProxies: proxies})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// rotating X-Forwarded-For doesn't give a new limit
+	for i, forwarded := range []string{"1.1.1.1", "2.2.2.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "200.40.1.1:5000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if i == 0 {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		}
+	}
+	assert.Equal(t, int64(1), limiter.used["ip:200.40.1.1"])
+}
@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/astropay/go-tools/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// keeps one entry per request in a sorted set, scored by time; returns
+// {allowed, remaining, retry after ms, reset after ms}
+var slidingWindowScript = goredis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+
+local allowed = 0
+local retry = 0
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", KEYS[1], now, ARGV[4] .. ":" .. i)
+	end
+	count = count + n
+	allowed = 1
+else
+	-- wait until enough entries leave the window
+	local idx = count + n - limit - 1
+	local entry = redis.call("ZRANGE", KEYS[1], idx, idx, "WITHSCORES")
+	retry = tonumber(entry[2]) + window - now
+end
+
+local reset = 0
+local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+if newest[2] then
+	reset = tonumber(newest[2]) + window - now
+	redis.call("PEXPIRE", KEYS[1], window)
+end
+
+return {allowed, limit - count, retry, reset}
+`)
+
+// keeps the available tokens and the last refill time in a hash; returns
+// {allowed, remaining, retry after ms, reset after ms}
+var tokenBucketScript = goredis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate / period)
+
+local allowed = 0
+local retry = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry = math.ceil((n - tokens) * period / rate)
+end
+
+local reset = math.ceil((capacity - tokens) * period / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// Config holds the limiter configuration
+type Config struct {
+	// Limit applies to all the keys, unless overridden
+	Limit Limit
+
+	// Overrides sets specific limits by key (ie: a merchant with a higher quota)
+	Overrides map[string]Limit
+
+	// KeyPrefix is prepended to the keys, after the Redis client prefix (default "ratelimit:")
+	KeyPrefix string
+}
+
+// RedisLimiter is a limiter backed by Redis
+type RedisLimiter struct {
+	client *redis.Client
+	config Config
+	script *goredis.Script
+	args   func(limit Limit, n int64) []interface{}
+	burst  bool
+}
+
+// NewSlidingWindow creates a sliding window limiter: at most `Limit.Rate` requests in any
+// `Limit.Period`
+func NewSlidingWindow(client *redis.Client, config Config) *RedisLimiter {
+	return newRedisLimiter(client, config, slidingWindowScript, false, func(limit Limit, n int64) []interface{} {
+		return []interface{}{limit.Rate, limit.Period.Milliseconds(), n, randomID()}
+	})
+}
+
+// NewTokenBucket creates a token bucket limiter: `Limit.Rate` tokens per `Limit.Period` are
+// refilled up to `Limit.Burst`
+func NewTokenBucket(client *redis.Client, config Config) *RedisLimiter {
+	return newRedisLimiter(client, config, tokenBucketScript, true, func(limit Limit, n int64) []interface{} {
+		return []interface{}{limit.capacity(), limit.Rate, limit.Period.Milliseconds(), n}
+	})
+}
+
+func newRedisLimiter(client *redis.Client, config Config, script *goredis.Script, burst bool, args func(Limit, int64) []interface{}) *RedisLimiter {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "ratelimit:"
+	}
+	return &RedisLimiter{client: client, config: config, script: script, burst: burst, args: args}
+}
+
+// LimitFor returns the limit that applies to the key
+func (l *RedisLimiter) LimitFor(key string) Limit {
+	if limit, found := l.config.Overrides[key]; found {
+		return limit
+	}
+	return l.config.Limit
+}
+
+// Allow is shorthand for AllowN(ctx, key, 1)
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN checks if `n` requests are allowed now and, if so, consumes them
+func (l *RedisLimiter) AllowN(ctx context.Context, key string, n int64) (result Result, err error) {
+	limit := l.LimitFor(key)
+	if err = limit.Validate(); err != nil {
+		return
+	}
+
+	if n <= 0 {
+		return result, ErrInvalidAmount
+	}
+
+	capacity := limit.Rate
+	if l.burst {
+		capacity = limit.capacity()
+	}
+
+	if n > capacity {
+		return result, ErrExceedsLimit
+	}
+
+	rdb, err := l.client.Get()
+	if err != nil {
+		return
+	}
+
+	values, err := l.script.Run(ctx, rdb, []string{l.client.Key(l.config.KeyPrefix + key)}, l.args(limit, n)...).Int64Slice()
+	if err != nil {
+		return
+	}
+
+	result = Result{
+		Allowed:    values[0] == 1,
+		Limit:      capacity,
+		Remaining:  values[1],
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}
+	return
+}
+
+// Reset removes the state of the key, so its limit is fully available again
+func (l *RedisLimiter) Reset(ctx context.Context, key string) error {
+	rdb, err := l.client.Get()
+	if err != nil {
+		return err
+	}
+	return rdb.Del(ctx, l.client.Key(l.config.KeyPrefix+key)).Err()
+}
+
+// returns a random id to make the sliding window entries unique
+func randomID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,107 @@
+// Package redis wraps github.com/go-redis/redis with the helpers we keep repeating in every
+// service: JSON values with TTL, pipelines, distributed counters and health checks.
+//
+//	client, err := redis.New(redis.Config{Address: "localhost:6379", KeyPrefix: "payments:"})
+//	err = client.SetJSON(ctx, "user:1", user, time.Minute)
+//	found, err := client.GetJSON(ctx, "user:1", &user)
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// Redis errors
+var (
+	ErrNoClient = errors.New("redis client not initialized")
+)
+
+// Config holds the configuration required to connect to a Redis server
+type Config struct {
+	Address  string
+	Username string
+	Password string
+	DB       int
+
+	// KeyPrefix is prepended to all the keys used through the helpers (optional)
+	KeyPrefix string
+
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Client is the Redis client wrapper
+type Client struct {
+	rdb    *goredis.Client
+	prefix string
+}
+
+// New connects to the Redis server and checks the connection is OK
+func New(config Config) (c *Client, err error) {
+	rdb := goredis.NewClient(&goredis.Options{
+		Addr:         config.Address,
+		Username:     config.Username,
+		Password:     config.Password,
+		DB:           config.DB,
+		PoolSize:     config.PoolSize,
+		MinIdleConns: config.MinIdleConns,
+		DialTimeout:  config.DialTimeout,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	})
+
+	c = &Client{rdb: rdb, prefix: config.KeyPrefix}
+
+	// ping server to check if it's OK
+	if err = c.Ping(context.Background()); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+
+	return
+}
+
+// NewFromClient wraps an already configured go-redis client
+func NewFromClient(rdb *goredis.Client, keyPrefix string) *Client {
+	return &Client{rdb: rdb, prefix: keyPrefix}
+}
+
+// Get returns the underlying go-redis client, for those operations not covered by the helpers
+func (c *Client) Get() (rdb *goredis.Client, err error) {
+	if c != nil && c.rdb != nil {
+		rdb = c.rdb
+	} else {
+		err = ErrNoClient
+	}
+	return
+}
+
+// Key returns the key with the configured prefix
+func (c *Client) Key(key string) string {
+	return c.prefix + key
+}
+
+// Ping checks the connection with the server
+func (c *Client) Ping(ctx context.Context) error {
+	if c == nil || c.rdb == nil {
+		return ErrNoClient
+	}
+	return c.rdb.Ping(ctx).Err()
+}
+
+// Close should be called when the server ends the execution,
+// so connections are gracefully released
+func (c *Client) Close() (err error) {
+	if c != nil && c.rdb != nil {
+		err = c.rdb.Close()
+	}
+	return
+}
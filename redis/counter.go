@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// increments the counter and sets the expiration when the key is created, atomically
+var incrementScript = goredis.NewScript(`
+local value = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// Counter is a distributed counter shared by all the instances using the same key.
+// When `ttl` is greater than zero the counter is reset once it expires (useful for
+// "attempts per hour" kind of limits).
+type Counter struct {
+	client *Client
+	key    string
+	ttl    time.Duration
+}
+
+// NewCounter creates a counter stored in the indicated key
+func (c *Client) NewCounter(key string, ttl time.Duration) *Counter {
+	return &Counter{client: c, key: c.Key(key), ttl: ttl}
+}
+
+// Incr increments the counter by one and returns the new value
+func (cnt *Counter) Incr(ctx context.Context) (int64, error) {
+	return cnt.IncrBy(ctx, 1)
+}
+
+// IncrBy increments the counter by `delta` and returns the new value
+func (cnt *Counter) IncrBy(ctx context.Context, delta int64) (int64, error) {
+	return incrementScript.Run(ctx, cnt.client.rdb, []string{cnt.key}, delta, cnt.ttl.Milliseconds()).Int64()
+}
+
+// Value returns the current value of the counter (zero if it doesn't exist)
+func (cnt *Counter) Value(ctx context.Context) (value int64, err error) {
+	if value, err = cnt.client.rdb.Get(ctx, cnt.key).Int64(); err == goredis.Nil {
+		err = nil
+	}
+	return
+}
+
+// Reset deletes the counter
+func (cnt *Counter) Reset(ctx context.Context) error {
+	return cnt.client.rdb.Del(ctx, cnt.key).Err()
+}
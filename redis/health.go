@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// default timeout used by health checks
+const defaultHealthTimeout = 2 * time.Second
+
+// HealthCheck returns a function that pings the server with the indicated timeout; it can be
+// plugged into any readiness endpoint expecting a `func(context.Context) error`
+func (c *Client) HealthCheck(timeout time.Duration) func(ctx context.Context) error {
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if err := c.Ping(ctx); err != nil {
+			return fmt.Errorf("redis: %s", err.Error())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// SetJSON stores the JSON representation of `value`; a `ttl` of zero means the key won't expire
+func (c *Client) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.rdb.Set(ctx, c.Key(key), data, ttl).Err()
+}
+
+// GetJSON reads the key and unmarshals its value into `dest`; `found` is false when the key doesn't exist
+func (c *Client) GetJSON(ctx context.Context, key string, dest interface{}) (found bool, err error) {
+	var data []byte
+
+	if data, err = c.rdb.Get(ctx, c.Key(key)).Bytes(); err != nil {
+		if err == goredis.Nil {
+			err = nil
+		}
+		return
+	}
+
+	if err = json.Unmarshal(data, dest); err == nil {
+		found = true
+	}
+
+	return
+}
+
+// SetJSONMulti stores several values in a single round trip, all of them with the same ttl
+func (c *Client) SetJSONMulti(ctx context.Context, values map[string]interface{}, ttl time.Duration) error {
+	_, err := c.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for k, v := range values {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, c.Key(k), data, ttl)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Delete removes the indicated keys
+func (c *Client) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i := range keys {
+		prefixed[i] = c.Key(keys[i])
+	}
+
+	return c.rdb.Del(ctx, prefixed...).Err()
+}
+
+// Exists returns true if the key exists
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.rdb.Exists(ctx, c.Key(key)).Result()
+	return n > 0, err
+}
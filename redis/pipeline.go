@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"context"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// Pipelined runs the commands queued by `fn` in a single round trip. Keys used inside `fn`
+// must be built with `Key()` so the prefix is applied.
+func (c *Client) Pipelined(ctx context.Context, fn func(pipe goredis.Pipeliner) error) ([]goredis.Cmder, error) {
+	cmds, err := c.rdb.Pipelined(ctx, fn)
+	if err == goredis.Nil {
+		// missing keys are reported on each command, not as a pipeline error
+		err = nil
+	}
+	return cmds, err
+}
+
+// TxPipelined is like Pipelined, but commands are wrapped in MULTI/EXEC
+func (c *Client) TxPipelined(ctx context.Context, fn func(pipe goredis.Pipeliner) error) ([]goredis.Cmder, error) {
+	cmds, err := c.rdb.TxPipelined(ctx, fn)
+	if err == goredis.Nil {
+		err = nil
+	}
+	return cmds, err
+}
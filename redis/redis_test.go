@@ -0,0 +1,189 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+
+	client, err := New(Config{Address: server.Addr(), KeyPrefix: "test:"})
+	if err != nil {
+		t.Fatalf("New() returned an error: %s", err.Error())
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client, server
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Address: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	if err == nil {
+		t.Errorf("New() should have returned an error")
+	}
+
+	var client *Client
+	_, err = client.Get()
+	assert.Equal(t, ErrNoClient, err)
+	assert.Equal(t, ErrNoClient, client.Ping(context.Background()))
+	assert.Nil(t, client.Close())
+}
+
+func TestJSON(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	assert.Nil(t, client.SetJSON(ctx, "user:1", user{ID: 1, Name: "John"}, time.Minute))
+	assert.True(t, server.Exists("test:user:1"))
+	assert.Equal(t, time.Minute, server.TTL("test:user:1"))
+
+	var u user
+	found, err := client.GetJSON(ctx, "user:1", &u)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, user{ID: 1, Name: "John"}, u)
+
+	found, err = client.GetJSON(ctx, "user:2", &u)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	// the key expires with the ttl
+	server.FastForward(time.Minute)
+	found, err = client.GetJSON(ctx, "user:1", &u)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	// invalid JSON
+	server.Set("test:user:3", "{")
+	found, err = client.GetJSON(ctx, "user:3", &u)
+	assert.NotNil(t, err)
+	assert.False(t, found)
+}
+
+func TestJSONMulti(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	err := client.SetJSONMulti(ctx, map[string]interface{}{
+		"user:1": user{ID: 1, Name: "John"},
+		"user:2": user{ID: 2, Name: "Jane"},
+	}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), server.TTL("test:user:2"))
+
+	exists, err := client.Exists(ctx, "user:2")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	assert.Nil(t, client.Delete(ctx, "user:1", "user:2"))
+	assert.Nil(t, client.Delete(ctx))
+
+	exists, err = client.Exists(ctx, "user:2")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	err = client.SetJSONMulti(ctx, map[string]interface{}{"invalid": make(chan int)}, 0)
+	assert.NotNil(t, err)
+}
+
+func TestCounter(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	counter := client.NewCounter("attempts", time.Hour)
+
+	value, err := counter.Value(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), value)
+
+	value, err = counter.Incr(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value)
+	assert.Equal(t, time.Hour, server.TTL("test:attempts"))
+
+	// the ttl is set when the counter is created, not on every increment
+	server.FastForward(30 * time.Minute)
+	value, err = counter.IncrBy(ctx, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), value)
+	assert.Equal(t, 30*time.Minute, server.TTL("test:attempts"))
+
+	// and the counter is reset once it expires
+	server.FastForward(30 * time.Minute)
+	value, err = counter.Value(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), value)
+
+	value, err = counter.Incr(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value)
+
+	assert.Nil(t, counter.Reset(ctx))
+	assert.False(t, server.Exists("test:attempts"))
+
+	// counters without ttl don't expire
+	counter = client.NewCounter("total", 0)
+	_, err = counter.Incr(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), server.TTL("test:total"))
+}
+
+func TestPipelined(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	server.Set("test:a", "1")
+
+	var a, b *goredis.StringCmd
+	cmds, err := client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		a = pipe.Get(ctx, client.Key("a"))
+		b = pipe.Get(ctx, client.Key("b"))
+		return nil
+	})
+
+	// missing keys are reported on each command
+	assert.Nil(t, err)
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, "1", a.Val())
+	assert.Equal(t, goredis.Nil, b.Err())
+
+	_, err = client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Incr(ctx, client.Key("a"))
+		pipe.Set(ctx, client.Key("b"), "2", 0)
+		return nil
+	})
+	assert.Nil(t, err)
+
+	value, _ := server.Get("test:a")
+	assert.Equal(t, "2", value)
+	value, _ = server.Get("test:b")
+	assert.Equal(t, "2", value)
+}
+
+func TestHealthCheck(t *testing.T) {
+	client, server := newTestClient(t)
+
+	check := client.HealthCheck(0)
+	assert.Nil(t, check(context.Background()))
+
+	server.SetError("server is down")
+	err := check(context.Background())
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "redis: ")
+	}
+
+	server.SetError("")
+	server.Close()
+	assert.NotNil(t, check(context.Background()))
+}
@@ -0,0 +1,273 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	infobipProviderName = "infobip"
+	infobipTokenParam   = "token"
+)
+
+// InfobipConfig holds the configuration required to send messages through Infobip
+type InfobipConfig struct {
+	// BaseURL is the account's personal base URL (ie: https://xxxxx.api.infobip.com)
+	BaseURL string
+	APIKey  string
+
+	// From is the default sender, used when the message doesn't specify one
+	From string
+
+	// NotifyURL is the public URL where Infobip will post the delivery reports (optional); Infobip
+	// doesn't sign them, so it must carry the secret checked by InfobipStatusHandler (ie: "?token=<secret>")
+	NotifyURL string
+
+	// HTTPClient used for the requests; defaults to a client with a 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+// InfobipSender sends messages using the Infobip SMS API
+type InfobipSender struct {
+	config InfobipConfig
+	client *http.Client
+}
+
+type infobipDestination struct {
+	To string `json:"to"`
+}
+
+type infobipMessage struct {
+	Destinations      []infobipDestination `json:"destinations"`
+	From              string               `json:"from"`
+	Text              string               `json:"text"`
+	NotifyURL         string               `json:"notifyUrl,omitempty"`
+	NotifyContentType string               `json:"notifyContentType,omitempty"`
+	CallbackData      string               `json:"callbackData,omitempty"`
+}
+
+type infobipRequest struct {
+	Messages []infobipMessage `json:"messages"`
+}
+
+type infobipStatus struct {
+	GroupName   string `json:"groupName"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type infobipError struct {
+	GroupName   string `json:"groupName"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type infobipResponse struct {
+	Messages []struct {
+		MessageID string        `json:"messageId"`
+		To        string        `json:"to"`
+		Status    infobipStatus `json:"status"`
+	} `json:"messages"`
+	RequestError *struct {
+		ServiceException struct {
+			MessageID string `json:"messageId"`
+			Text      string `json:"text"`
+		} `json:"serviceException"`
+	} `json:"requestError"`
+}
+
+type infobipReports struct {
+	Results []struct {
+		MessageID    string        `json:"messageId"`
+		To           string        `json:"to"`
+		CallbackData string        `json:"callbackData"`
+		Status       infobipStatus `json:"status"`
+		Error        infobipError  `json:"error"`
+	} `json:"results"`
+}
+
+// NewInfobipSender creates a new Infobip sender
+func NewInfobipSender(config InfobipConfig) *InfobipSender {
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &InfobipSender{config: config, client: client}
+}
+
+// Name returns the provider name
+func (s *InfobipSender) Name() string {
+	return infobipProviderName
+}
+
+// Send sends the message
+func (s *InfobipSender) Send(ctx context.Context, msg Message) (result Result, err error) {
+	if err = msg.Validate(); err != nil {
+		return
+	}
+
+	var from string
+	if from, err = resolveFrom(msg, s.config.From); err != nil {
+		return
+	}
+
+	payload := infobipRequest{
+		Messages: []infobipMessage{
+			{
+				// Infobip expects international format without the leading '+'
+				Destinations: []infobipDestination{{To: strings.TrimPrefix(msg.To, "+")}},
+				From:         strings.TrimPrefix(from, "+"),
+				Text:         msg.Body,
+				CallbackData: msg.Reference,
+			},
+		},
+	}
+
+	if s.config.NotifyURL != "" {
+		payload.Messages[0].NotifyURL = s.config.NotifyURL
+		payload.Messages[0].NotifyContentType = "application/json"
+	}
+
+	var reqBody []byte
+	if reqBody, err = json.Marshal(payload); err != nil {
+		return
+	}
+
+	endpoint := strings.TrimRight(s.config.BaseURL, "/") + "/sms/2/text/advanced"
+
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(reqBody)); err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "App "+s.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	response := new(infobipResponse)
+	jsonErr := json.Unmarshal(body, response)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		providerErr := &ProviderError{Provider: infobipProviderName, StatusCode: resp.StatusCode, Message: string(body)}
+		if jsonErr == nil && response.RequestError != nil {
+			providerErr.Code = response.RequestError.ServiceException.MessageID
+			providerErr.Message = response.RequestError.ServiceException.Text
+		}
+		err = providerErr
+		return
+	}
+
+	if jsonErr != nil {
+		err = fmt.Errorf("infobip: invalid response: %s", jsonErr.Error())
+		return
+	}
+
+	if len(response.Messages) == 0 {
+		err = fmt.Errorf("infobip: response has no messages")
+		return
+	}
+
+	status := mapInfobipStatus(response.Messages[0].Status.GroupName)
+	if status == StatusFailed || status == StatusUndelivered {
+		err = &ProviderError{
+			Provider:   infobipProviderName,
+			StatusCode: resp.StatusCode,
+			Code:       response.Messages[0].Status.Name,
+			Message:    response.Messages[0].Status.Description,
+		}
+		return
+	}
+
+	result = Result{
+		Provider:  infobipProviderName,
+		MessageID: response.Messages[0].MessageID,
+		Status:    status,
+		SentAt:    time.Now(),
+	}
+
+	return
+}
+
+// InfobipStatusHandler returns an http.Handler that receives Infobip's delivery reports
+// and forwards each one of them to `callback`.
+//
+// Requests must carry `secret` in the 'token' query parameter of the NotifyURL; they're rejected
+// when it doesn't match, or when `secret` is empty.
+func InfobipStatusHandler(secret string, callback StatusCallback) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.URL.Query().Get(infobipTokenParam)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		reports := new(infobipReports)
+		if err := json.NewDecoder(r.Body).Decode(reports); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for _, res := range reports.Results {
+			report := DeliveryReport{
+				Provider:   infobipProviderName,
+				MessageID:  res.MessageID,
+				Reference:  res.CallbackData,
+				To:         "+" + strings.TrimPrefix(res.To, "+"),
+				Status:     mapInfobipStatus(res.Status.GroupName),
+				ReceivedAt: time.Now(),
+			}
+
+			if res.Error.ID != 0 {
+				report.ErrorCode = res.Error.Name
+				report.ErrorMessage = res.Error.Description
+			}
+
+			if callback != nil {
+				callback(report)
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// maps Infobip status group to our internal representation
+func mapInfobipStatus(group string) string {
+	switch group {
+	case "PENDING":
+		return StatusQueued
+	case "DELIVERED":
+		return StatusDelivered
+	case "UNDELIVERABLE", "EXPIRED":
+		return StatusUndelivered
+	case "REJECTED":
+		return StatusFailed
+	default:
+		return StatusUnknown
+	}
+}
@@ -0,0 +1,88 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const mockProviderName = "mock"
+
+// MockSender is a test double for `Sender`; it doesn't send anything, it just
+// keeps the messages so tests can check what was sent
+type MockSender struct {
+	mutex    sync.Mutex
+	messages []Message
+
+	// Err, when set, is returned by Send and the message is not recorded
+	Err error
+
+	// Callback, when set, receives a delivered report for every message sent
+	Callback StatusCallback
+}
+
+// NewMockSender creates a new mock sender
+func NewMockSender() *MockSender {
+	return &MockSender{}
+}
+
+// Name returns the provider name
+func (m *MockSender) Name() string {
+	return mockProviderName
+}
+
+// Send records the message
+func (m *MockSender) Send(ctx context.Context, msg Message) (result Result, err error) {
+	if err = msg.Validate(); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	if m.Err != nil {
+		err = m.Err
+		m.mutex.Unlock()
+		return
+	}
+	m.messages = append(m.messages, msg)
+	id := fmt.Sprintf("mock-%d", len(m.messages))
+	callback := m.Callback
+	m.mutex.Unlock()
+
+	result = Result{Provider: mockProviderName, MessageID: id, Status: StatusSent, SentAt: time.Now()}
+
+	if callback != nil {
+		callback(DeliveryReport{Provider: mockProviderName, MessageID: id, To: msg.To, Reference: msg.Reference, Status: StatusDelivered, ReceivedAt: time.Now()})
+	}
+
+	return
+}
+
+// Messages returns a copy of all the messages sent so far
+func (m *MockSender) Messages() []Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
+
+// LastMessage returns the last message sent; found is false when nothing was sent
+func (m *MockSender) LastMessage() (msg Message, found bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.messages) == 0 {
+		return
+	}
+
+	return m.messages[len(m.messages)-1], true
+}
+
+// Reset clears the recorded messages
+func (m *MockSender) Reset() {
+	m.mutex.Lock()
+	m.messages = nil
+	m.mutex.Unlock()
+}
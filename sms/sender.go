@@ -0,0 +1,140 @@
+// Package sms provides a provider-agnostic way of sending text messages (mainly OTP codes).
+//
+// Every provider implements the `Sender` interface, so services can switch providers
+// (or use the `MockSender` in tests) without touching the code that sends the messages:
+//
+//	sender := sms.NewTwilioSender(sms.TwilioConfig{AccountSID: "...", AuthToken: "...", From: "+15005550006"})
+//	result, err := sender.Send(ctx, sms.Message{To: "+59899123456", Body: "Your code is 123456"})
+//
+// Delivery status notifications sent back by the providers can be received by mounting the
+// provider's callback handler (see `TwilioStatusHandler` and `InfobipStatusHandler`).
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/astropay/go-tools/common"
+)
+
+// Message status values, normalized for all the providers
+const (
+	StatusQueued      = "queued"
+	StatusSent        = "sent"
+	StatusDelivered   = "delivered"
+	StatusUndelivered = "undelivered"
+	StatusFailed      = "failed"
+	StatusUnknown     = "unknown"
+)
+
+// SMS errors
+var (
+	ErrInvalidPhoneNumber = errors.New("phone number must be in E.164 format")
+	ErrEmptyMessage       = errors.New("message body can't be empty")
+	ErrMissingSender      = errors.New("sender (from) is not configured")
+)
+
+// ProviderError is returned when the provider rejects the message
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: request failed with status %d (code: %s): %s", e.Provider, e.StatusCode, e.Code, e.Message)
+}
+
+// Message is the text message to be sent
+type Message struct {
+	// To is the destination phone number, in E.164 format
+	To string
+
+	// From overrides the sender configured in the provider (optional)
+	From string
+
+	// Body is the text to send
+	Body string
+
+	// Reference is an optional internal identifier that is kept along with the message, and sent
+	// back in the delivery reports (for Twilio, only when a StatusCallbackURL is configured)
+	Reference string
+}
+
+// Result holds the information returned by the provider after accepting a message
+type Result struct {
+	Provider  string
+	MessageID string
+	Status    string
+	SentAt    time.Time
+}
+
+// DeliveryReport is the delivery status notification received from a provider
+type DeliveryReport struct {
+	Provider     string
+	MessageID    string
+	Reference    string
+	To           string
+	Status       string
+	ErrorCode    string
+	ErrorMessage string
+	ReceivedAt   time.Time
+}
+
+// StatusCallback is invoked for every delivery report received from a provider
+type StatusCallback func(report DeliveryReport)
+
+// Sender is the common interface implemented by all the SMS providers
+type Sender interface {
+	Send(ctx context.Context, msg Message) (Result, error)
+	Name() string
+}
+
+// Validate checks the message can be sent
+func (m Message) Validate() error {
+	if !common.IsE164PhoneNumber(m.To) {
+		return ErrInvalidPhoneNumber
+	}
+
+	if m.From != "" && !common.IsE164PhoneNumber(m.From) && !isAlphanumericSender(m.From) {
+		return ErrInvalidPhoneNumber
+	}
+
+	if m.Body == "" {
+		return ErrEmptyMessage
+	}
+
+	return nil
+}
+
+// resolves the sender to use for a message
+func resolveFrom(msg Message, defaultFrom string) (from string, err error) {
+	if from = msg.From; from == "" {
+		from = defaultFrom
+	}
+
+	if from == "" {
+		err = ErrMissingSender
+	}
+
+	return
+}
+
+// alphanumeric sender IDs (ie: "AstroPay") are accepted by most providers;
+// they're limited to 11 characters
+func isAlphanumericSender(from string) bool {
+	if len(from) == 0 || len(from) > 11 {
+		return false
+	}
+
+	for _, r := range from {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == ' ') {
+			return false
+		}
+	}
+
+	return true
+}
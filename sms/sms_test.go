@@ -0,0 +1,241 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageValidate(t *testing.T) {
+	assert.Nil(t, Message{To: "+59899123456", Body: "code: 1234"}.Validate())
+	assert.Nil(t, Message{To: "+59899123456", From: "AstroPay", Body: "code: 1234"}.Validate())
+	assert.Equal(t, ErrInvalidPhoneNumber, Message{To: "099123456", Body: "code: 1234"}.Validate())
+	assert.Equal(t, ErrInvalidPhoneNumber, Message{To: "+59899123456", From: "NotAValidSenderID", Body: "code: 1234"}.Validate())
+	assert.Equal(t, ErrEmptyMessage, Message{To: "+59899123456"}.Validate())
+}
+
+func TestTwilioSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "token", pass)
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+
+		r.ParseForm()
+		assert.Equal(t, "+59899123456", r.PostForm.Get("To"))
+		assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM1", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{AccountSID: "AC123", AuthToken: "token", From: "+15005550006", BaseURL: server.URL})
+	result, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 1234"})
+	if err != nil {
+		t.Fatalf("Send() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, "SM1", result.MessageID)
+	assert.Equal(t, StatusQueued, result.Status)
+}
+
+func TestTwilioSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number", "status": 400}`))
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{AccountSID: "AC123", AuthToken: "token", From: "+15005550006", BaseURL: server.URL})
+	_, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 1234"})
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected a ProviderError, got: %v", err)
+	}
+	assert.Equal(t, "21211", providerErr.Code)
+	assert.Equal(t, http.StatusBadRequest, providerErr.StatusCode)
+}
+
+func TestTwilioStatusHandler(t *testing.T) {
+	publicURL := "https://example.com/sms/status"
+	params := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}, "To": {"+59899123456"}}
+
+	var received []DeliveryReport
+	handler := TwilioStatusHandler("token", publicURL, func(report DeliveryReport) {
+		received = append(received, report)
+	})
+
+	// valid signature
+	req := httptest.NewRequest(http.MethodPost, "/sms/status", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", TwilioSignature("token", publicURL, params))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "SM1", received[0].MessageID)
+		assert.Equal(t, StatusDelivered, received[0].Status)
+	}
+
+	// invalid signature
+	req = httptest.NewRequest(http.MethodPost, "/sms/status", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", "invalid")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Len(t, received, 1)
+
+	// the reference comes back in the query string, which is signed too
+	query := "?reference=ref-1"
+	req = httptest.NewRequest(http.MethodPost, "/sms/status"+query, strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", TwilioSignature("token", publicURL+query, params))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "ref-1", received[1].Reference)
+	}
+
+	// without a token, every request is rejected
+	handler = TwilioStatusHandler("", publicURL, func(report DeliveryReport) {
+		received = append(received, report)
+	})
+
+	req = httptest.NewRequest(http.MethodPost, "/sms/status", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", TwilioSignature("", publicURL, params))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Len(t, received, 2)
+}
+
+func TestTwilioSendReference(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "https://example.com/sms/status?reference=ref-1", r.PostForm.Get("StatusCallback"))
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM1", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender(TwilioConfig{
+		AccountSID:        "AC123",
+		AuthToken:         "token",
+		From:              "+15005550006",
+		StatusCallbackURL: "https://example.com/sms/status",
+		BaseURL:           server.URL,
+	})
+
+	_, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 1234", Reference: "ref-1"})
+	assert.Nil(t, err)
+}
+
+func TestInfobipSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "App key", r.Header.Get("Authorization"))
+		assert.Equal(t, "/sms/2/text/advanced", r.URL.Path)
+
+		payload := new(infobipRequest)
+		json.NewDecoder(r.Body).Decode(payload)
+		assert.Equal(t, "59899123456", payload.Messages[0].Destinations[0].To)
+		assert.Equal(t, "ref-1", payload.Messages[0].CallbackData)
+
+		w.Write([]byte(`{"bulkId": "B1", "messages": [{"messageId": "M1", "to": "59899123456", "status": {"groupName": "PENDING", "name": "PENDING_ACCEPTED"}}]}`))
+	}))
+	defer server.Close()
+
+	sender := NewInfobipSender(InfobipConfig{BaseURL: server.URL, APIKey: "key", From: "AstroPay"})
+	result, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 1234", Reference: "ref-1"})
+	if err != nil {
+		t.Fatalf("Send() returned an error: %s", err.Error())
+	}
+
+	assert.Equal(t, "M1", result.MessageID)
+	assert.Equal(t, StatusQueued, result.Status)
+}
+
+func TestInfobipStatusHandler(t *testing.T) {
+	body := `{"results": [
+		{"messageId": "M1", "to": "59899123456", "callbackData": "ref-1", "status": {"groupName": "DELIVERED"}, "error": {"id": 0, "name": "NO_ERROR"}},
+		{"messageId": "M2", "to": "59899123457", "status": {"groupName": "UNDELIVERABLE"}, "error": {"id": 1, "name": "EC_UNKNOWN_SUBSCRIBER"}}
+	]}`
+
+	var received []DeliveryReport
+	handler := InfobipStatusHandler("secret", func(report DeliveryReport) {
+		received = append(received, report)
+	})
+
+	// missing or invalid token
+	for _, target := range []string{"/sms/status", "/sms/status?token=invalid"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+	assert.Empty(t, received)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sms/status?token=secret", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, StatusDelivered, received[0].Status)
+		assert.Equal(t, "ref-1", received[0].Reference)
+		assert.Equal(t, "+59899123456", received[0].To)
+		assert.Equal(t, StatusUndelivered, received[1].Status)
+		assert.Equal(t, "EC_UNKNOWN_SUBSCRIBER", received[1].ErrorCode)
+	}
+}
+
+func TestInfobipStatusHandlerNoSecret(t *testing.T) {
+	handler := InfobipStatusHandler("", func(report DeliveryReport) {
+		t.Errorf("callback shouldn't be called")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sms/status?token=", strings.NewReader(`{"results": []}`)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestMockSender(t *testing.T) {
+	var delivered []DeliveryReport
+	mock := NewMockSender()
+	mock.Callback = func(report DeliveryReport) { delivered = append(delivered, report) }
+
+	var sender Sender = mock
+	if _, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 1234", Reference: "ref-1"}); err != nil {
+		t.Fatalf("Send() returned an error: %s", err.Error())
+	}
+
+	msg, found := mock.LastMessage()
+	assert.True(t, found)
+	assert.Equal(t, "Your code is 1234", msg.Body)
+	if assert.Len(t, delivered, 1) {
+		assert.Equal(t, "ref-1", delivered[0].Reference)
+		assert.Equal(t, StatusDelivered, delivered[0].Status)
+	}
+
+	mock.Err = errors.New("provider down")
+	_, err := sender.Send(context.Background(), Message{To: "+59899123456", Body: "Your code is 5678"})
+	assert.NotNil(t, err)
+	assert.Len(t, mock.Messages(), 1)
+
+	mock.Reset()
+	assert.Len(t, mock.Messages(), 0)
+}
@@ -0,0 +1,260 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	twilioProviderName    = "twilio"
+	twilioDefaultBaseURL  = "https://api.twilio.com"
+	twilioSignatureHeader = "X-Twilio-Signature"
+)
+
+// TwilioConfig holds the configuration required to send messages through Twilio
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+
+	// From is the default sender, used when the message doesn't specify one
+	From string
+
+	// StatusCallbackURL is the public URL where Twilio will post the delivery reports (optional);
+	// the message reference is sent back in its 'reference' query parameter
+	StatusCallbackURL string
+
+	// BaseURL can be used to point to a different API host (tests); defaults to the Twilio API
+	BaseURL string
+
+	// HTTPClient used for the requests; defaults to a client with a 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+// TwilioSender sends messages using the Twilio Programmable Messaging API
+type TwilioSender struct {
+	config TwilioConfig
+	client *http.Client
+}
+
+type twilioMessageResponse struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
+type twilioErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewTwilioSender creates a new Twilio sender
+func NewTwilioSender(config TwilioConfig) *TwilioSender {
+	if config.BaseURL == "" {
+		config.BaseURL = twilioDefaultBaseURL
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &TwilioSender{config: config, client: client}
+}
+
+// Name returns the provider name
+func (s *TwilioSender) Name() string {
+	return twilioProviderName
+}
+
+// Send sends the message
+func (s *TwilioSender) Send(ctx context.Context, msg Message) (result Result, err error) {
+	if err = msg.Validate(); err != nil {
+		return
+	}
+
+	var from string
+	if from, err = resolveFrom(msg, s.config.From); err != nil {
+		return
+	}
+
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", from)
+	form.Set("Body", msg.Body)
+	if s.config.StatusCallbackURL != "" {
+		var callbackURL string
+		if callbackURL, err = twilioCallbackURL(s.config.StatusCallbackURL, msg.Reference); err != nil {
+			return
+		}
+		form.Set("StatusCallback", callbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(s.config.BaseURL, "/"), s.config.AccountSID)
+
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode())); err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(s.config.AccountSID, s.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		providerErr := &ProviderError{Provider: twilioProviderName, StatusCode: resp.StatusCode, Message: string(body)}
+		errResponse := new(twilioErrorResponse)
+		if json.Unmarshal(body, errResponse) == nil && errResponse.Code != 0 {
+			providerErr.Code = fmt.Sprint(errResponse.Code)
+			providerErr.Message = errResponse.Message
+		}
+		err = providerErr
+		return
+	}
+
+	response := new(twilioMessageResponse)
+	if jsonErr := json.Unmarshal(body, response); jsonErr != nil {
+		err = fmt.Errorf("twilio: invalid response: %s", jsonErr.Error())
+		return
+	}
+
+	result = Result{
+		Provider:  twilioProviderName,
+		MessageID: response.SID,
+		Status:    mapTwilioStatus(response.Status),
+		SentAt:    time.Now(),
+	}
+
+	return
+}
+
+// TwilioStatusHandler returns an http.Handler that receives Twilio's status callbacks and
+// forwards them to `callback`.
+//
+// Requests are validated using the X-Twilio-Signature header, and rejected when `authToken` is
+// empty; `publicURL` must be the StatusCallbackURL configured in the sender (the one used to
+// compute the signature, without the reference).
+func TwilioStatusHandler(authToken, publicURL string, callback StatusCallback) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if authToken == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		// the signature includes the query string, where the reference is sent
+		signedURL := publicURL
+		if r.URL.RawQuery != "" {
+			signedURL = strings.SplitN(publicURL, "?", 2)[0] + "?" + r.URL.RawQuery
+		}
+
+		expected := TwilioSignature(authToken, signedURL, r.PostForm)
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get(twilioSignatureHeader))) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		report := DeliveryReport{
+			Provider:     twilioProviderName,
+			MessageID:    r.PostForm.Get("MessageSid"),
+			Reference:    r.URL.Query().Get("reference"),
+			To:           r.PostForm.Get("To"),
+			Status:       mapTwilioStatus(r.PostForm.Get("MessageStatus")),
+			ErrorCode:    r.PostForm.Get("ErrorCode"),
+			ErrorMessage: r.PostForm.Get("ErrorMessage"),
+			ReceivedAt:   time.Now(),
+		}
+
+		if callback != nil {
+			callback(report)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// TwilioSignature computes the signature Twilio sends in the X-Twilio-Signature header:
+// base64(HMAC-SHA1(authToken, url + sorted param names and values))
+func TwilioSignature(authToken, publicURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(publicURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			sb.WriteString(k)
+			sb.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(sb.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// adds the message reference to the status callback URL
+func twilioCallbackURL(callbackURL, reference string) (string, error) {
+	if reference == "" {
+		return callbackURL, nil
+	}
+
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", fmt.Errorf("twilio: invalid status callback url: %s", err.Error())
+	}
+
+	query := u.Query()
+	query.Set("reference", reference)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// maps Twilio message status to our internal representation
+func mapTwilioStatus(status string) string {
+	switch status {
+	case "accepted", "scheduled", "queued", "sending":
+		return StatusQueued
+	case "sent":
+		return StatusSent
+	case "delivered", "read":
+		return StatusDelivered
+	case "undelivered":
+		return StatusUndelivered
+	case "failed", "canceled":
+		return StatusFailed
+	default:
+		return StatusUnknown
+	}
+}